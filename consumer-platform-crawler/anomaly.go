@@ -0,0 +1,164 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+)
+
+// Price anomaly thresholds. A new price is quarantined when it moves more
+// than maxPriceChange from the last recorded price, or when it lies more than
+// maxPriceZScore standard deviations from the recent mean.
+const (
+	maxPriceChange = 0.5
+	maxPriceZScore = 4.0
+	// priceHistoryWindow recent prices form the baseline; the z-score needs
+	// at least minPriceHistory of them.
+	priceHistoryWindow = 20
+	minPriceHistory    = 5
+	// minPriceSpread floors the standard deviation at this fraction of the
+	// mean, so a product that never changed price is not flagged for an
+	// ordinary promotion
+	minPriceSpread = 0.1
+	// a re-crawl within priceConfirmTolerance of a quarantined price
+	// confirms it
+	priceConfirmTolerance = 0.02
+)
+
+// Review states stored in price_quarantine.status.
+const (
+	quarantinePending   = "pending"
+	quarantineConfirmed = "confirmed"
+	quarantineRejected  = "rejected"
+)
+
+// priceAnomaly describes why a price looks wrong.
+type priceAnomaly struct {
+	Previous float64
+	Change   float64
+	ZScore   float64
+	Reason   string
+}
+
+// detectPriceAnomaly compares price with history, newest first. It returns
+// nil when the price is plausible or there is no history to compare with.
+func detectPriceAnomaly(price float64, history []float64) *priceAnomaly {
+	if len(history) == 0 || history[0] <= 0 {
+		return nil
+	}
+	a := &priceAnomaly{Previous: history[0], Change: (price - history[0]) / history[0]}
+	if len(history) >= minPriceHistory {
+		var sum, sq float64
+		for _, p := range history {
+			sum += p
+		}
+		mean := sum / float64(len(history))
+		for _, p := range history {
+			sq += (p - mean) * (p - mean)
+		}
+		sd := math.Max(math.Sqrt(sq/float64(len(history))), minPriceSpread*mean)
+		if sd > 0 {
+			a.ZScore = (price - mean) / sd
+		}
+	}
+	switch {
+	case math.Abs(a.Change) > maxPriceChange:
+		a.Reason = fmt.Sprintf("%+.0f%% vs last price %.2f", 100*a.Change, a.Previous)
+	case math.Abs(a.ZScore) > maxPriceZScore:
+		a.Reason = fmt.Sprintf("z-score %.1f over the last %d prices", a.ZScore, len(history))
+	default:
+		return nil
+	}
+	return a
+}
+
+// checkPriceAnomaly decides whether p's price may be written. A price is held
+// back (hold=true) and quarantined when it is anomalous against the product's
+// history; a pending quarantined price is confirmed once a re-crawl sees it
+// again, and rejected once a re-crawl sees something else.
+func checkPriceAnomaly(db *sql.DB, p productData, runID string) (hold bool, a *priceAnomaly, err error) {
+	productID, err := findProductID(db, p)
+	if err != nil || productID == 0 {
+		return false, nil, err
+	}
+
+	var qID int64
+	var qPrice float64
+	err = db.QueryRow(`
+        SELECT id, price FROM price_quarantine
+        WHERE product_id = ? AND status = ? ORDER BY id DESC LIMIT 1
+    `, productID, quarantinePending).Scan(&qID, &qPrice)
+	switch {
+	case err == sql.ErrNoRows:
+	case err != nil:
+		return false, nil, err
+	case qPrice > 0 && math.Abs(p.PriceNumeric-qPrice)/qPrice <= priceConfirmTolerance:
+		return false, nil, resolveQuarantine(db, qID, quarantineConfirmed)
+	default:
+		if err := resolveQuarantine(db, qID, quarantineRejected); err != nil {
+			return false, nil, err
+		}
+	}
+
+	history, err := recentPrices(db, productID, priceHistoryWindow)
+	if err != nil {
+		return false, nil, err
+	}
+	a = detectPriceAnomaly(p.PriceNumeric, history)
+	if a == nil {
+		return false, nil, nil
+	}
+	_, err = db.Exec(`
+        INSERT INTO price_quarantine (product_id, run_id, url, price, price_text, previous_price, pct_change, z_score, reason, status)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `, productID, runID, p.SourceURL, p.PriceNumeric, p.PriceText, a.Previous, 100*a.Change, a.ZScore, a.Reason, quarantinePending)
+	if err != nil {
+		return false, nil, err
+	}
+	return true, a, nil
+}
+
+// findProductID returns the id of the stored row for p, or 0 if it is new.
+func findProductID(db *sql.DB, p productData) (int64, error) {
+	retailer := p.Retailer
+	if retailer == "" {
+		retailer = obramatRetailer
+	}
+	var id int64
+	err := db.QueryRow(`
+        SELECT id FROM products
+        WHERE source_url = ? OR (? <> '' AND retailer = ? AND reference = ?)
+        ORDER BY id LIMIT 1
+    `, p.SourceURL, p.Reference, retailer, p.Reference).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return id, err
+}
+
+// recentPrices returns up to n recorded prices of a product, newest first.
+func recentPrices(db *sql.DB, productID int64, n int) ([]float64, error) {
+	rows, err := db.Query(`
+        SELECT price FROM product_price_history
+        WHERE product_id = ? AND price IS NOT NULL AND price > 0
+        ORDER BY recorded_at DESC, id DESC LIMIT ?
+    `, productID, n)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var prices []float64
+	for rows.Next() {
+		var p float64
+		if err := rows.Scan(&p); err != nil {
+			return nil, err
+		}
+		prices = append(prices, p)
+	}
+	return prices, rows.Err()
+}
+
+func resolveQuarantine(db *sql.DB, id int64, status string) error {
+	_, err := db.Exec(`UPDATE price_quarantine SET status = ?, resolved_at = NOW() WHERE id = ?`, status, id)
+	return err
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// blockedError reports that the retailer served an anti-bot challenge or block
+// page instead of the product.
+type blockedError struct {
+	URL    string
+	Status int64
+	Reason string
+}
+
+func (e *blockedError) Error() string {
+	return fmt.Sprintf("blocked (%s): status %d, %s", e.URL, e.Status, e.Reason)
+}
+
+// errCircuitOpen stops a run after too many consecutive blocks.
+var errCircuitOpen = errors.New("too many consecutive blocked pages, crawl stopped")
+
+// blockTitleMarkers are lower-cased page titles used by common bot walls.
+var blockTitleMarkers = []string{
+	"just a moment",
+	"attention required",
+	"access denied",
+	"pardon our interruption",
+	"are you a robot",
+	"robot check",
+	"verificación de seguridad",
+}
+
+// blockHTMLMarkers are lower-cased snippets found only on challenge pages.
+// Cloudflare's /cdn-cgi/challenge-platform/ script is not one of them: bot
+// management injects it into ordinary pages too.
+var blockHTMLMarkers = []string{
+	"captcha-delivery.com", // DataDome
+	"cf-challenge",         // Cloudflare
+	"cf-chl-",
+	"_incapsula_resource", // Imperva
+	"/distil_r_captcha",
+}
+
+// captchaHTMLMarkers are lower-cased captcha widget snippets. Product pages
+// can carry one too, e.g. in a newsletter or review form, so they only count
+// on pages without product markup.
+var captchaHTMLMarkers = []string{
+	"px-captcha", // PerimeterX
+	"g-recaptcha",
+	"h-captcha",
+}
+
+// productMarkupRe matches the schema.org Product markup of a product page.
+var productMarkupRe = regexp.MustCompile(`"@type"\s*:\s*"product"|itemtype="https?://schema\.org/product"`)
+
+// classifyBlock returns a non-empty reason when the response looks like a
+// block or challenge page.
+func classifyBlock(status int64, title, html string) string {
+	switch status {
+	case 403, 429, 503:
+		return fmt.Sprintf("http %d", status)
+	}
+	t := strings.ToLower(title)
+	for _, m := range blockTitleMarkers {
+		if strings.Contains(t, m) {
+			return fmt.Sprintf("title %q", title)
+		}
+	}
+	h := strings.ToLower(html)
+	for _, m := range blockHTMLMarkers {
+		if strings.Contains(h, m) {
+			return fmt.Sprintf("marker %q", m)
+		}
+	}
+	if productMarkupRe.MatchString(h) {
+		return ""
+	}
+	for _, m := range captchaHTMLMarkers {
+		if strings.Contains(h, m) {
+			return fmt.Sprintf("captcha %q without product markup", m)
+		}
+	}
+	return ""
+}
+
+// detectBlock inspects the loaded page and returns a *blockedError if it is a
+// challenge page. status is the main document HTTP status, or 0 if unknown.
+func detectBlock(ctx context.Context, pageURL string, status int64) error {
+	var title, html string
+	if err := chromedp.Run(ctx,
+		chromedp.Title(&title),
+		chromedp.Evaluate(`document.documentElement ? document.documentElement.outerHTML.slice(0, 200000) : ''`, &html),
+	); err != nil {
+		if reason := classifyBlock(status, "", ""); reason != "" {
+			return &blockedError{URL: pageURL, Status: status, Reason: reason}
+		}
+		return nil
+	}
+	if reason := classifyBlock(status, title, html); reason != "" {
+		return &blockedError{URL: pageURL, Status: status, Reason: reason}
+	}
+	return nil
+}
+
+// blockGuard tracks blocks during a run. Each block puts the domain on an
+// exponentially growing cooldown; a successful page resets it. After
+// maxConsecutive blocks in a row the circuit opens and the run stops.
+type blockGuard struct {
+	maxConsecutive int
+	baseCooldown   time.Duration
+	maxCooldown    time.Duration
+
+	consecutive int
+	cooldown    map[string]time.Duration
+	until       map[string]time.Time
+}
+
+func newBlockGuard(maxConsecutive int) *blockGuard {
+	if maxConsecutive <= 0 {
+		maxConsecutive = 5
+	}
+	return &blockGuard{
+		maxConsecutive: maxConsecutive,
+		baseCooldown:   time.Minute,
+		maxCooldown:    30 * time.Minute,
+		cooldown:       map[string]time.Duration{},
+		until:          map[string]time.Time{},
+	}
+}
+
+// wait blocks until the cooldown for rawURL's domain has passed.
+func (g *blockGuard) wait(ctx context.Context, rawURL string) error {
+	host := hostOf(rawURL)
+	d := time.Until(g.until[host])
+	if d <= 0 {
+		return nil
+	}
+	slog.Warn("cooling down host", "host", host, "remaining", d.Round(time.Second).String())
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// record updates the guard with the outcome of one URL. It returns
+// errCircuitOpen once the consecutive block limit is reached.
+func (g *blockGuard) record(rawURL string, err error) error {
+	host := hostOf(rawURL)
+	var blocked *blockedError
+	if !errors.As(err, &blocked) {
+		g.consecutive = 0
+		delete(g.cooldown, host)
+		delete(g.until, host)
+		return nil
+	}
+	g.consecutive++
+	next := g.cooldown[host] * 2
+	if next == 0 {
+		next = g.baseCooldown
+	}
+	if next > g.maxCooldown {
+		next = g.maxCooldown
+	}
+	g.cooldown[host] = next
+	g.until[host] = time.Now().Add(next)
+	slog.Warn("page blocked", "url", blocked.URL, "reason", blocked.Reason, "consecutive", g.consecutive, "max_consecutive", g.maxConsecutive, "cooldown", next.String())
+	if g.consecutive >= g.maxConsecutive {
+		return errCircuitOpen
+	}
+	return nil
+}
+
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return u.Host
+}
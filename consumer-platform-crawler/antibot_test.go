@@ -0,0 +1,33 @@
+package main
+
+import "testing"
+
+func TestClassifyBlock(t *testing.T) {
+	const product = `<script type="application/ld+json">{"@type": "Product", "sku": "25087500"}</script>`
+	tests := []struct {
+		name    string
+		status  int64
+		title   string
+		html    string
+		blocked bool
+	}{
+		{"product page", 200, "Sierra de calar Bosch", product, false},
+		{"cloudflare bot management script", 200, "Sierra de calar Bosch", product + `<script src="/cdn-cgi/challenge-platform/scripts/jsd/main.js"></script>`, false},
+		{"recaptcha form on a product page", 200, "Sierra de calar Bosch", product + `<div class="g-recaptcha" data-sitekey="x"></div>`, false},
+		{"microdata product page with hcaptcha", 200, "Sierra de calar Bosch", `<div itemtype="https://schema.org/Product"><div class="h-captcha"></div></div>`, false},
+		{"cloudflare interstitial", 200, "obramat.es", `<div id="cf-chl-widget-abc"></div><script src="/cdn-cgi/challenge-platform/h/g/orchestrate/chl_page/v1"></script>`, true},
+		{"just a moment title", 200, "Just a moment...", ``, true},
+		{"captcha without product markup", 200, "obramat.es", `<div class="g-recaptcha"></div>`, true},
+		{"perimeterx captcha", 200, "", `<div id="px-captcha"></div>`, true},
+		{"datadome", 200, "", `<iframe src="https://geo.captcha-delivery.com/captcha/"></iframe>`, true},
+		{"forbidden", 403, "", ``, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason := classifyBlock(tt.status, tt.title, tt.html)
+			if (reason != "") != tt.blocked {
+				t.Errorf("classifyBlock = %q, want blocked %v", reason, tt.blocked)
+			}
+		})
+	}
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	cdplog "github.com/chromedp/cdproto/log"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+)
+
+// Caps keep a runaway page from filling memory before it fails.
+const (
+	maxConsoleEntries = 500
+	maxHAREntries     = 2000
+)
+
+// saveSnapshot captures the current page HTML into dir/page.html.
+func saveSnapshot(ctx context.Context, dir string) error {
+	var html string
+	if err := chromedp.Run(ctx, chromedp.OuterHTML("html", &html, chromedp.ByQuery)); err != nil {
+		return fmt.Errorf("snapshot capture: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("snapshot mkdir: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "page.html"), []byte(html), 0o644); err != nil {
+		return fmt.Errorf("snapshot write: %w", err)
+	}
+	return nil
+}
+
+// artifactRecorder collects console errors and network traffic of a tab so
+// they can be written out if the product fails. It must be attached before
+// navigation, like networkTracker.
+type artifactRecorder struct {
+	mu       sync.Mutex
+	console  []string
+	entries  []*harEntry
+	inflight map[network.RequestID]*harEntry
+}
+
+func recordArtifacts(ctx context.Context) *artifactRecorder {
+	r := &artifactRecorder{inflight: map[network.RequestID]*harEntry{}}
+	chromedp.ListenTarget(ctx, func(ev any) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		switch ev := ev.(type) {
+		case *runtime.EventConsoleAPICalled:
+			if ev.Type == runtime.APITypeError || ev.Type == runtime.APITypeWarning {
+				r.addConsole(string(ev.Type), consoleArgs(ev.Args))
+			}
+		case *runtime.EventExceptionThrown:
+			if d := ev.ExceptionDetails; d != nil {
+				text := d.Text
+				if d.Exception != nil && d.Exception.Description != "" {
+					text += " " + d.Exception.Description
+				}
+				r.addConsole("exception", fmt.Sprintf("%s (%s:%d)", text, d.URL, d.LineNumber))
+			}
+		case *cdplog.EventEntryAdded:
+			if e := ev.Entry; e != nil && (e.Level == cdplog.LevelError || e.Level == cdplog.LevelWarning) {
+				r.addConsole(string(e.Level), fmt.Sprintf("[%s] %s %s", e.Source, e.Text, e.URL))
+			}
+		case *network.EventRequestWillBeSent:
+			// redirects reuse the request id: close the hop that redirected
+			if prev, ok := r.inflight[ev.RequestID]; ok && ev.RedirectResponse != nil {
+				prev.setResponse(ev.RedirectResponse)
+				prev.finish(time.Now())
+			}
+			if len(r.entries) >= maxHAREntries {
+				return
+			}
+			e := newHAREntry(ev.Request, time.Now())
+			r.entries = append(r.entries, e)
+			r.inflight[ev.RequestID] = e
+		case *network.EventResponseReceived:
+			if e, ok := r.inflight[ev.RequestID]; ok {
+				e.setResponse(ev.Response)
+			}
+		case *network.EventLoadingFinished:
+			if e, ok := r.inflight[ev.RequestID]; ok {
+				e.Response.BodySize = int64(ev.EncodedDataLength)
+				e.finish(time.Now())
+				delete(r.inflight, ev.RequestID)
+			}
+		case *network.EventLoadingFailed:
+			if e, ok := r.inflight[ev.RequestID]; ok {
+				e.Response.Error = ev.ErrorText
+				e.finish(time.Now())
+				delete(r.inflight, ev.RequestID)
+			}
+		}
+	})
+	return r
+}
+
+func (r *artifactRecorder) addConsole(level, text string) {
+	if len(r.console) >= maxConsoleEntries {
+		return
+	}
+	r.console = append(r.console, fmt.Sprintf("%s %-9s %s", time.Now().Format(time.RFC3339Nano), level, text))
+}
+
+func consoleArgs(args []*runtime.RemoteObject) string {
+	parts := make([]string, 0, len(args))
+	for _, a := range args {
+		switch {
+		case a.Description != "":
+			parts = append(parts, a.Description)
+		case len(a.Value) > 0:
+			var s string
+			if json.Unmarshal(a.Value, &s) == nil {
+				parts = append(parts, s)
+			} else {
+				parts = append(parts, string(a.Value))
+			}
+		default:
+			parts = append(parts, string(a.Type))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// save writes screenshot.jpg, page.html, console.log and network.har to dir.
+// Every artifact is attempted; the returned error lists those that failed.
+func (r *artifactRecorder) save(ctx context.Context, dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	var errs []error
+	var shot []byte
+	if err := chromedp.Run(ctx, chromedp.FullScreenshot(&shot, 80)); err != nil {
+		errs = append(errs, fmt.Errorf("screenshot: %w", err))
+	} else if err := os.WriteFile(filepath.Join(dir, "screenshot.jpg"), shot, 0o644); err != nil {
+		errs = append(errs, fmt.Errorf("screenshot: %w", err))
+	}
+	if err := saveSnapshot(ctx, dir); err != nil {
+		errs = append(errs, err)
+	}
+
+	r.mu.Lock()
+	console := strings.Join(r.console, "\n")
+	har, err := json.MarshalIndent(r.har(), "", "  ")
+	r.mu.Unlock()
+	if console != "" {
+		console += "\n"
+	}
+	if err := os.WriteFile(filepath.Join(dir, "console.log"), []byte(console), 0o644); err != nil {
+		errs = append(errs, fmt.Errorf("console log: %w", err))
+	}
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, "network.har"), har, 0o644)
+	}
+	if err != nil {
+		errs = append(errs, fmt.Errorf("har: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// unsafePathRe matches characters not wanted in artifact directory names.
+var unsafePathRe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// artifactDir returns root/<run id>/<time>-<reference or page slug>.
+func artifactDir(root, runID, rawURL string) string {
+	name := referenceFromURL(rawURL)
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(strings.TrimSuffix(rawURL, "/")), ".html")
+	}
+	name = strings.Trim(unsafePathRe.ReplaceAllString(name, "-"), "-")
+	if len(name) > 80 {
+		name = name[:80]
+	}
+	return filepath.Join(root, runID, time.Now().Format("150405.000")+"-"+name)
+}
+
+// The HAR 1.2 subset below carries what is useful to debug a failed page:
+// every request with its status, headers, size and timing. Bodies are not
+// kept.
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	Cookies     []harNameValue `json:"cookies"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+}
+
+type harResponse struct {
+	Status      int64          `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []harNameValue `json:"headers"`
+	Cookies     []harNameValue `json:"cookies"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+	Error       string         `json:"_error,omitempty"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+type harEntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+}
+
+func newHAREntry(req *network.Request, start time.Time) *harEntry {
+	e := &harEntry{
+		StartedDateTime: start,
+		Request: harRequest{
+			HTTPVersion: "HTTP/1.1",
+			Headers:     []harNameValue{},
+			QueryString: []harNameValue{},
+			Cookies:     []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		Response: harResponse{
+			HTTPVersion: "HTTP/1.1",
+			Headers:     []harNameValue{},
+			Cookies:     []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+	}
+	if req != nil {
+		e.Request.Method = req.Method
+		e.Request.URL = req.URL + req.URLFragment
+		e.Request.Headers = harHeaders(req.Headers)
+	}
+	return e
+}
+
+func (e *harEntry) setResponse(resp *network.Response) {
+	e.Response.Status = resp.Status
+	e.Response.StatusText = resp.StatusText
+	e.Response.Headers = harHeaders(resp.Headers)
+	e.Response.Content = harContent{Size: int64(resp.EncodedDataLength), MimeType: resp.MimeType}
+	if resp.Protocol != "" {
+		e.Response.HTTPVersion = strings.ToUpper(resp.Protocol)
+	}
+	for _, h := range e.Response.Headers {
+		if strings.EqualFold(h.Name, "location") {
+			e.Response.RedirectURL = h.Value
+		}
+	}
+}
+
+func (e *harEntry) finish(end time.Time) {
+	e.Time = float64(end.Sub(e.StartedDateTime).Microseconds()) / 1000
+	e.Timings.Wait = e.Time
+}
+
+func harHeaders(h network.Headers) []harNameValue {
+	out := make([]harNameValue, 0, len(h))
+	for k, v := range h {
+		out = append(out, harNameValue{Name: k, Value: fmt.Sprint(v)})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// har wraps the recorded entries in a HAR log. Callers hold r.mu.
+func (r *artifactRecorder) har() any {
+	return map[string]any{
+		"log": map[string]any{
+			"version": "1.2",
+			"creator": map[string]string{"name": "obramat-crawler", "version": "1"},
+			"entries": r.entries,
+		},
+	}
+}
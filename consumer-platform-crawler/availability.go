@@ -0,0 +1,202 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// stockStatus is the normalised availability of a product in a store.
+type stockStatus string
+
+const (
+	stockInStock    stockStatus = "in_stock"
+	stockLow        stockStatus = "low_stock"
+	stockOutOfStock stockStatus = "out_of_stock"
+	stockOnOrder    stockStatus = "on_order"
+	stockUnknown    stockStatus = "unknown"
+)
+
+// lowStockThreshold is the quantity at or below which an in-stock product is
+// reported as low_stock.
+const lowStockThreshold = 5
+
+// availability is the parsed form of an Obramat stock message such as
+// "11 en stock", "+100 disponibles" or "Sense estoc".
+type availability struct {
+	Status stockStatus
+	// Quantity is nil when the message carries no number.
+	Quantity *int
+	// QuantityIsLowerBound is set for "+100" / "más de 100" style counts.
+	QuantityIsLowerBound bool
+	ClickAndCollect      bool
+	HomeDelivery         bool
+	// Raw is the original message, stored as availability_text.
+	Raw string
+}
+
+// Markers are matched against the lower-cased, accent-stripped message, so
+// they are written without accents. Order matters: out-of-stock and on-order
+// phrases often also contain "disponible" or "stock".
+var (
+	outOfStockMarkers = []string{
+		"sin stock", "sin existencias", "no hay stock", "agotado", "no disponible",
+		"sense estoc", "sense stock", "esgotat", "exhaurit",
+		"out of stock", "unavailable",
+	}
+	onOrderMarkers = []string{
+		"a partir de", "bajo pedido", "por encargo", "a pedido", "proximamente", "reposicion", "en camino",
+		"sota comanda", "per encarrec", "properament", "reposicio",
+		"on order", "backorder",
+	}
+	lowStockMarkers = []string{
+		"ultimas unidades", "pocas unidades", "stock bajo", "stock limitado", "quedan",
+		"ultimes unitats", "poques unitats", "estoc baix", "estoc limitat", "queden",
+		"low stock",
+	}
+	inStockMarkers = []string{
+		"en stock", "disponible", "en tienda",
+		"en estoc", "a botiga",
+		"in stock", "available",
+	}
+	clickAndCollectMarkers = []string{
+		"recogida", "recoger", "retirar en tienda",
+		"recollida", "recollir",
+		"click & collect", "click and collect", "click&collect",
+	}
+	// a bare "entrega"/"lliurament" can mean collection too, so it only
+	// counts with a home qualifier
+	homeDeliveryMarkers = []string{
+		"envio", "a domicilio", "entrega en casa",
+		"enviament", "a domicili", "lliurament a casa",
+		"delivery",
+	}
+	noDeliveryMarkers = []string{"sin envio", "envio no disponible", "sense enviament", "enviament no disponible"}
+	// stockWords keep a delivery clause in the stock text, as in "sin stock
+	// para envio".
+	stockWords = []string{"stock", "estoc", "existencias", "unidades", "unitats"}
+)
+
+var (
+	accentReplacer = strings.NewReplacer(
+		"á", "a", "à", "a", "é", "e", "è", "e", "í", "i", "ï", "i",
+		"ó", "o", "ò", "o", "ú", "u", "ü", "u", "_", " ",
+	)
+	// quantityRe captures an optional "+"/"más de"/"més de" prefix, the number
+	// and an optional "+" suffix, e.g. "+100", "100+", "mas de 100".
+	quantityRe = regexp.MustCompile(`(\+\s*|mas de\s+|mes de\s+)?(\d+)(\s*\+)?`)
+	// durationRe recognises numbers that are lead times ("en 48h", "3 dias").
+	durationRe = regexp.MustCompile(`^\s*(h|hrs?|horas?|hores?|dias?|dies|min)\b`)
+	// clauseRe splits a message into clauses; a period only ends one when
+	// followed by a space, so "1.000" stays whole.
+	clauseRe = regexp.MustCompile(`[.;,|](\s+|$)|\n`)
+)
+
+// stockClauses drops the clauses about delivery or collection, so "envio no
+// disponible", "entrega en 24/48h" or "lliurament en 24/48h" do not read as
+// stock statements.
+func stockClauses(t string) string {
+	var keep []string
+	for _, c := range clauseRe.Split(t, -1) {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+		delivery := containsAny(c, homeDeliveryMarkers) || containsAny(c, clickAndCollectMarkers) ||
+			strings.HasPrefix(c, "entrega") || strings.HasPrefix(c, "lliurament")
+		if delivery && !containsAny(c, stockWords) {
+			continue
+		}
+		keep = append(keep, c)
+	}
+	return strings.Join(keep, ". ")
+}
+
+// isDatePart reports whether the number at t[start:end] is part of a date or
+// a range like "12/03" or "24/48h".
+func isDatePart(t string, start, end int) bool {
+	return start > 0 && t[start-1] == '/' || end < len(t) && t[end] == '/'
+}
+
+// parseAvailability classifies a stock message.
+func parseAvailability(text string) availability {
+	a := availability{Status: stockUnknown, Raw: strings.TrimSpace(text)}
+	t := strings.Join(strings.Fields(accentReplacer.Replace(strings.ToLower(text))), " ")
+	if t == "" {
+		return a
+	}
+
+	a.ClickAndCollect = containsAny(t, clickAndCollectMarkers)
+	a.HomeDelivery = containsAny(t, homeDeliveryMarkers) && !containsAny(t, noDeliveryMarkers)
+
+	// counts and stock phrases are only read from the stock clauses; a plain
+	// "disponible para envio" still says the product is available
+	s := stockClauses(t)
+	for _, m := range quantityRe.FindAllStringSubmatchIndex(s, -1) {
+		if durationRe.MatchString(s[m[1]:]) || isDatePart(s, m[4], m[5]) {
+			continue
+		}
+		if n, err := strconv.Atoi(s[m[4]:m[5]]); err == nil {
+			a.Quantity = &n
+			a.QuantityIsLowerBound = m[2] >= 0 || m[6] >= 0
+		}
+		break
+	}
+
+	switch {
+	case containsAny(s, outOfStockMarkers):
+		a.Status = stockOutOfStock
+	case containsAny(s, onOrderMarkers):
+		a.Status = stockOnOrder
+	case a.Quantity != nil && *a.Quantity == 0:
+		a.Status = stockOutOfStock
+	case containsAny(s, lowStockMarkers):
+		a.Status = stockLow
+	case a.Quantity != nil:
+		a.Status = stockInStock
+		if *a.Quantity <= lowStockThreshold && !a.QuantityIsLowerBound {
+			a.Status = stockLow
+		}
+	case containsAny(s, inStockMarkers), a.HomeDelivery && containsAny(t, inStockMarkers):
+		a.Status = stockInStock
+	}
+	return a
+}
+
+func containsAny(s string, markers []string) bool {
+	for _, m := range markers {
+		if strings.Contains(s, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// withQuantity overrides the parsed count with an exact one (from the stock
+// API) and derives the status from it when the message alone was not enough.
+func (a availability) withQuantity(n int) availability {
+	a.Quantity = &n
+	a.QuantityIsLowerBound = false
+	if a.Raw == "" {
+		a.Raw = strconv.Itoa(n)
+	}
+	if a.Status == stockUnknown || a.Status == stockInStock || a.Status == stockLow {
+		switch {
+		case n == 0:
+			a.Status = stockOutOfStock
+		case n <= lowStockThreshold:
+			a.Status = stockLow
+		default:
+			a.Status = stockInStock
+		}
+	}
+	return a
+}
+
+// quantityOrNil returns the quantity for the nullable stock column.
+func (a availability) quantityOrNil() any {
+	if a.Quantity == nil {
+		return nil
+	}
+	return *a.Quantity
+}
@@ -0,0 +1,66 @@
+package main
+
+import "testing"
+
+func TestParseAvailability(t *testing.T) {
+	tests := []struct {
+		text       string
+		status     stockStatus
+		quantity   int // -1 for none
+		lowerBound bool
+		delivery   bool
+	}{
+		{"11 en stock", stockInStock, 11, false, false},
+		{"+100 disponibles", stockInStock, 100, true, false},
+		{"Más de 50 en stock", stockInStock, 50, true, false},
+		{"3 en stock", stockLow, 3, false, false},
+		{"0 en stock", stockOutOfStock, 0, false, false},
+		{"Sin stock", stockOutOfStock, -1, false, false},
+		{"Agotado", stockOutOfStock, -1, false, false},
+		{"Últimas unidades", stockLow, -1, false, false},
+		{"Bajo pedido", stockOnOrder, -1, false, false},
+		{"Disponible en tienda", stockInStock, -1, false, false},
+		{"11 en stock. Envío no disponible", stockInStock, 11, false, false},
+		{"11 en stock. Envío a domicilio en 48h", stockInStock, 11, false, true},
+		{"Envío no disponible", stockUnknown, -1, false, false},
+		{"Disponible para envío a domicilio", stockInStock, -1, false, true},
+		{"Entrega en 24/48h", stockUnknown, -1, false, false},
+		{"Entrega a domicilio en 24/48h", stockUnknown, -1, false, true},
+		{"Disponible a partir del 12/03", stockOnOrder, -1, false, false},
+		{"Sin stock para envío a domicilio", stockOutOfStock, -1, false, true},
+		// Catalan
+		{"12 en estoc", stockInStock, 12, false, false},
+		{"Més de 100 en estoc", stockInStock, 100, true, false},
+		{"Sense estoc", stockOutOfStock, -1, false, false},
+		{"Esgotat", stockOutOfStock, -1, false, false},
+		{"Últimes unitats", stockLow, -1, false, false},
+		{"Sota comanda", stockOnOrder, -1, false, false},
+		{"Disponible a botiga", stockInStock, -1, false, false},
+		{"8 en estoc. Enviament no disponible", stockInStock, 8, false, false},
+		{"Lliurament en 24/48h", stockUnknown, -1, false, false},
+		{"Lliurament a domicili en 24/48h", stockUnknown, -1, false, true},
+		{"", stockUnknown, -1, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			a := parseAvailability(tt.text)
+			if a.Status != tt.status {
+				t.Errorf("status = %s, want %s", a.Status, tt.status)
+			}
+			switch {
+			case tt.quantity < 0 && a.Quantity != nil:
+				t.Errorf("quantity = %d, want none", *a.Quantity)
+			case tt.quantity >= 0 && a.Quantity == nil:
+				t.Errorf("quantity = none, want %d", tt.quantity)
+			case tt.quantity >= 0 && *a.Quantity != tt.quantity:
+				t.Errorf("quantity = %d, want %d", *a.Quantity, tt.quantity)
+			}
+			if a.QuantityIsLowerBound != tt.lowerBound {
+				t.Errorf("lower bound = %t, want %t", a.QuantityIsLowerBound, tt.lowerBound)
+			}
+			if a.HomeDelivery != tt.delivery {
+				t.Errorf("home delivery = %t, want %t", a.HomeDelivery, tt.delivery)
+			}
+		})
+	}
+}
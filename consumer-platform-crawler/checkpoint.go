@@ -0,0 +1,84 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// checkpoint records how far a one-shot crawl got so `-continue` can pick up
+// at the next unprocessed URL.
+type checkpoint struct {
+	RunID     string    `json:"run_id"`
+	URLsFile  string    `json:"urls_file"`
+	NextIndex int       `json:"next_index"`
+	NextURL   string    `json:"next_url"`
+	Total     int       `json:"total"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// newRunID returns a sortable identifier such as 20261019-143712-a1b2c3.
+func newRunID() string {
+	b := make([]byte, 3)
+	_, _ = rand.Read(b)
+	return time.Now().Format("20060102-150405") + "-" + hex.EncodeToString(b)
+}
+
+// loadCheckpoint reads a checkpoint file. It returns (nil, nil) if none exists.
+func loadCheckpoint(path string) (*checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cp checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("parse checkpoint %s: %w", path, err)
+	}
+	return &cp, nil
+}
+
+// saveCheckpoint writes the checkpoint atomically so an interrupted write
+// never leaves a truncated file behind.
+func saveCheckpoint(path string, cp checkpoint) error {
+	cp.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func removeCheckpoint(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// resumeIndex finds where to continue in urls. The stored URL wins over the
+// stored index so that edits to the URL file before the stop point are handled.
+func (cp *checkpoint) resumeIndex(urls []string) (int, error) {
+	if cp.NextIndex >= len(urls) && cp.NextURL == "" {
+		return len(urls), nil
+	}
+	if cp.NextIndex < len(urls) && urls[cp.NextIndex] == cp.NextURL {
+		return cp.NextIndex, nil
+	}
+	for i, u := range urls {
+		if u == cp.NextURL {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("checkpoint URL %s (index %d) not found in %s", cp.NextURL, cp.NextIndex, cp.URLsFile)
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// crawlOptions controls a single crawl run over a list of product URLs.
+type crawlOptions struct {
+	SkipExisting bool
+	RunID        string
+	// StartIndex skips URLs before it (used by -continue).
+	StartIndex int
+	// URLsFile and CheckpointPath enable checkpointing: after every URL the
+	// index of the next one is written to CheckpointPath, and the file is
+	// removed once the run completes.
+	URLsFile       string
+	CheckpointPath string
+	// MaxConsecutiveBlocks opens the circuit breaker after that many blocked
+	// pages in a row (default 5).
+	MaxConsecutiveBlocks int
+	// Rotator assigns proxies and fingerprints per tab; nil disables rotation.
+	Rotator *rotator
+	// Validation sets the severity of each data quality rule; nil uses
+	// defaultValidationRules.
+	Validation validationRules
+	// ArtifactsDir, if set, receives a screenshot, DOM, console log and HAR
+	// for every failed URL, under <ArtifactsDir>/<RunID>/.
+	ArtifactsDir string
+	// MetricsFile, if set, is rewritten with the current metrics after every
+	// URL for node_exporter's textfile collector.
+	MetricsFile string
+}
+
+// errNavigate marks failures to load the page at all, as opposed to failures
+// extracting data from a loaded page.
+var errNavigate = errors.New("navigate failed")
+
+// readURLList reads one product URL per line, skipping blank lines.
+func readURLList(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var urls []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			urls = append(urls, line)
+		}
+	}
+	return urls, nil
+}
+
+// newBrowser starts a Chrome instance and returns its root context together
+// with a cancel func that closes the browser and releases the allocator.
+func newBrowser() (context.Context, context.CancelFunc) {
+	allocCtx, allocCancel := chromedp.NewExecAllocator(context.Background(),
+		append(chromedp.DefaultExecAllocatorOptions[:],
+			chromedp.Flag("headless", false), // show browser; headful often passes more checks
+			chromedp.UserDataDir(os.ExpandEnv(`./chrome-profile`)),
+			chromedp.Flag("disable-blink-features", "AutomationControlled"),
+			chromedp.Flag("start-maximized", true),
+			chromedp.UserAgent("Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"),
+		)...,
+	)
+	ctx, cancel := chromedp.NewContext(allocCtx)
+	return ctx, func() {
+		cancel()
+		allocCancel()
+	}
+}
+
+// crawlURLs scrapes every URL in order using a fresh browser. Cancelling ctx
+// stops the run before the next URL; the product in flight is always finished
+// because the browser contexts are not derived from ctx.
+func crawlURLs(ctx context.Context, db *sql.DB, urls []string, opts crawlOptions) error {
+	browserCtx, closeBrowser := newBrowser()
+	defer closeBrowser()
+	guard := newBlockGuard(opts.MaxConsecutiveBlocks)
+	lg := slog.With("run_id", opts.RunID)
+
+	for i := opts.StartIndex; i < len(urls); i++ {
+		url := urls[i]
+		if err := ctx.Err(); err != nil {
+			lg.Info("run stopped", "next_url", url, "index", i, "total", len(urls), "err", err)
+			return err
+		}
+		if err := guard.wait(ctx, url); err != nil {
+			lg.Info("run stopped during cooldown", "next_url", url, "err", err)
+			return err
+		}
+		err := crawlOne(browserCtx, db, url, opts)
+		if errors.Is(err, errNoProxies) {
+			lg.Error("run stopped", "url", url, "index", i, "total", len(urls), "err", err)
+			return err
+		}
+		if err := guard.record(url, err); err != nil {
+			// The blocked URL is not checkpointed so -continue retries it.
+			lg.Error("run stopped", "url", url, "index", i, "total", len(urls), "err", err)
+			return err
+		}
+		writeCheckpoint(opts, urls, i+1)
+		if opts.MetricsFile != "" {
+			if err := writeMetricsFile(opts.MetricsFile); err != nil {
+				lg.Warn("metrics file write failed", "err", err)
+			}
+		}
+	}
+
+	if opts.CheckpointPath != "" {
+		if err := removeCheckpoint(opts.CheckpointPath); err != nil {
+			lg.Warn("checkpoint cleanup failed", "err", err)
+		}
+	}
+	return nil
+}
+
+// crawlOne processes a single URL. Ordinary failures are logged here; the
+// error is returned so the caller can react to blocks.
+func crawlOne(browserCtx context.Context, db *sql.DB, url string, opts crawlOptions) error {
+	lg := slog.With("run_id", opts.RunID, "url", url)
+	if opts.SkipExisting {
+		exists, err := productExists(db, obramatRetailer, url)
+		if err != nil {
+			lg.Error("existence check failed", "step", "db", "err", err)
+			metrics.recordPage(pageSkipped, nil)
+			return nil
+		}
+		if exists {
+			lg.Info("skip existing")
+			metrics.recordPage(pageSkipped, nil)
+			return nil
+		}
+	}
+	tabCtx, closeTab, proxy, err := opts.Rotator.openTab(browserCtx)
+	if err != nil {
+		lg.Error("open tab failed", "step", "open_tab", "err", err)
+		metrics.recordPage(pageFailed, err)
+		return err
+	}
+	metrics.tabOpened()
+	defer func() {
+		closeTab()
+		metrics.tabClosed()
+	}()
+	tabCtx = withLogger(tabCtx, lg)
+	var rec *artifactRecorder
+	if opts.ArtifactsDir != "" {
+		rec = recordArtifacts(tabCtx)
+	}
+	err = processURL(tabCtx, db, url, opts)
+	opts.Rotator.report(proxy, err)
+	var blocked *blockedError
+	var gone *goneError
+	switch {
+	case err == nil:
+		metrics.recordPage(pageOK, nil)
+	case errors.As(err, &gone):
+		metrics.recordPage(pageGone, err)
+	default:
+		metrics.recordPage(pageFailed, err)
+		recordFailure(tabCtx, db, url, opts, rec, err)
+	}
+	if err != nil && !errors.As(err, &blocked) {
+		lg.Error("product failed", "step", failureClass(err), "err", err)
+	}
+	return err
+}
+
+// recordFailure saves the tab's failure artifacts, if recorded, and logs the
+// failure to crawl_failures. The tab is still open on the failed page.
+func recordFailure(tabCtx context.Context, db *sql.DB, url string, opts crawlOptions, rec *artifactRecorder, cause error) {
+	lg := loggerFrom(tabCtx)
+	f := crawlFailure{
+		RunID:     opts.RunID,
+		URL:       url,
+		Reference: referenceFromURL(url),
+		Class:     failureClass(cause),
+		Error:     cause.Error(),
+	}
+	if rec != nil {
+		f.ArtifactsDir = artifactDir(opts.ArtifactsDir, opts.RunID, url)
+		ctx, cancel := context.WithTimeout(tabCtx, 20*time.Second)
+		if err := rec.save(ctx, f.ArtifactsDir); err != nil {
+			lg.Warn("failure artifacts incomplete", "dir", f.ArtifactsDir, "err", err)
+		} else {
+			lg.Info("saved failure artifacts", "dir", f.ArtifactsDir)
+		}
+		cancel()
+	}
+	if err := InsertCrawlFailure(db, f); err != nil {
+		lg.Error("crawl failure insert failed", "step", "db", "err", err)
+	}
+}
+
+// writeCheckpoint records next as the first unprocessed URL index.
+func writeCheckpoint(opts crawlOptions, urls []string, next int) {
+	if opts.CheckpointPath == "" {
+		return
+	}
+	cp := checkpoint{
+		RunID:     opts.RunID,
+		URLsFile:  opts.URLsFile,
+		NextIndex: next,
+		Total:     len(urls),
+	}
+	if next < len(urls) {
+		cp.NextURL = urls[next]
+	}
+	if err := saveCheckpoint(opts.CheckpointPath, cp); err != nil {
+		slog.Warn("checkpoint write failed", "run_id", opts.RunID, "err", err)
+	}
+}
+
+// processURL scrapes a single product page in tabCtx, validates it and
+// persists it.
+func processURL(tabCtx context.Context, db *sql.DB, url string, opts crawlOptions) error {
+	lg := loggerFrom(tabCtx)
+	lg.Info("processing")
+	prod, err := extractObramat(tabCtx, url)
+	var gone *goneError
+	if errors.As(err, &gone) {
+		if markErr := MarkProductGone(db, obramatRetailer, url, gone.Status); markErr != nil {
+			lg.Error("lifecycle update failed", "step", "db", "err", markErr)
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	lg = lg.With("reference", prod.Reference)
+	lg.Info("extracted", "step", "extract", "title", prod.Title, "price", prod.PriceText,
+		"availability", prod.Availability.Raw, "stock_status", prod.Availability.Status)
+
+	check := validateProduct(&prod, opts.Validation)
+	for _, issue := range check.Issues {
+		lg.Warn("validation issue", "step", "validation", "rule", issue.Rule, "severity", issue.Severity, "detail", issue.Message)
+	}
+	if len(check.Issues) > 0 {
+		if err := InsertValidationIssues(db, opts.RunID, url, prod.Reference, check.Issues); err != nil {
+			lg.Error("validation issue insert failed", "step", "db", "err", err)
+		}
+	}
+	if check.Rejected {
+		return stepFailed("validation", fmt.Errorf("validation rejected (%s): %s", url, check.rejectReasons()))
+	}
+	hold, anomaly, err := checkPriceAnomaly(db, prod, opts.RunID)
+	if err != nil {
+		lg.Error("price anomaly check failed", "step", "anomaly", "err", err)
+	}
+	if hold {
+		prod.HoldPrice = true
+		lg.Warn("price quarantined until a re-crawl confirms it", "step", "anomaly",
+			"price", prod.PriceNumeric, "previous_price", anomaly.Previous, "reason", anomaly.Reason)
+	}
+
+	start := time.Now()
+	productID, err := UpsertProduct(db, prod)
+	metrics.observeDBWrite("product", start)
+	if err != nil {
+		return stepFailed("db", fmt.Errorf("product Upsert failed (%s): %w", url, err))
+	}
+	if prod.RedirectedFrom != "" {
+		if err := MarkProductRedirected(db, prod.RedirectedFrom, prod.SourceURL); err != nil {
+			lg.Error("lifecycle update failed", "step", "db", "redirected_from", prod.RedirectedFrom, "err", err)
+		}
+	}
+	if !prod.HoldPrice {
+		start = time.Now()
+		if err := InsertPriceHistory(db, productID, prod); err != nil {
+			lg.Error("price history insert failed", "step", "db", "err", err)
+		}
+		metrics.observeDBWrite("price_history", start)
+	}
+	start = time.Now()
+	if err := UpsertImages(db, productID, prod.CarouselImages); err != nil {
+		lg.Error("images upsert failed", "step", "db", "err", err)
+	}
+	metrics.observeDBWrite("images", start)
+	start = time.Now()
+	if err := UpsertTechDoc(db, productID, prod.TechDocURL); err != nil {
+		lg.Error("tech doc upsert failed", "step", "db", "err", err)
+	}
+	metrics.observeDBWrite("tech_doc", start)
+	start = time.Now()
+	if err := UpsertAttributes(db, productID, prod.Attributes); err != nil {
+		lg.Error("attributes upsert failed", "step", "db", "err", err)
+	}
+	metrics.observeDBWrite("attributes", start)
+	start = time.Now()
+	defer metrics.observeDBWrite("availability", start)
+	if len(prod.Stores) > 0 {
+		for _, s := range prod.Stores {
+			city, name := s.dbNames()
+			if err := UpsertAvailability(db, productID, city, name, s.availability()); err != nil {
+				lg.Error("availability upsert failed", "step", "db", "store", name, "err", err)
+			}
+			if err := InsertAvailabilityHistory(db, productID, city, name, s.availability()); err != nil {
+				lg.Error("availability history insert failed", "step", "db", "store", name, "err", err)
+			}
+		}
+	} else {
+		if err := UpsertAvailability(db, productID, prod.StoreCity, prod.StoreName, prod.Availability); err != nil {
+			lg.Error("availability upsert failed", "step", "db", "store", prod.StoreName, "err", err)
+		}
+		if err := InsertAvailabilityHistory(db, productID, prod.StoreCity, prod.StoreName, prod.Availability); err != nil {
+			lg.Error("availability history insert failed", "step", "db", "store", prod.StoreName, "err", err)
+		}
+	}
+
+	lg.Info("saved product", "step", "db", "product_id", productID)
+	return nil
+}
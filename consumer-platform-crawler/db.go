@@ -5,28 +5,56 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql"
 )
 
 type productData struct {
+    // Retailer names the extractor that produced the row, e.g. "obramat".
+    Retailer        string
     SourceURL       string
+    // Reference is the retailer's product id (e.g. 25022742), the identity
+    // used to recognise the same product under different URLs.
+    Reference       string
+    // EAN, Brand and Model identify the product across retailers; see
+    // matching.go. Empty when the page does not expose them.
+    EAN             string
+    Brand           string
+    Model           string
+    // Category is the page's breadcrumb below the home page, e.g.
+    // "Herramientas > Taladros"; the seller maps it to a marketplace category.
+    Category        string
     Title           string
     Description     string
     PriceNumeric    float64
     PriceText       string
     Currency        string
+    // HoldPrice keeps the stored price while the scraped one is quarantined
+    // as an anomaly; see anomaly.go.
+    HoldPrice       bool
     CarouselImages  []string
     TechDocURL      string
-    AvailabilityQty string
-    AvailabilityRaw string
+    // Attributes are the specifications the page lists in its structured
+    // data, e.g. Potencia: 18 V. The seller uses them in descriptions.
+    Attributes      []productAttribute
+    Availability    availability
     StoreCity       string
     StoreName       string
+    // Stores holds every store from the availability API, when it was captured.
+    Stores          []storeStock
+    // RedirectedFrom is the requested URL when it redirected to SourceURL.
+    RedirectedFrom  string
 }
 
-func productExists(db *sql.DB, sourceURL string) (bool, error) {
+func productExists(db *sql.DB, retailer, sourceURL string) (bool, error) {
     var id int64
-    err := db.QueryRow(`SELECT id FROM products WHERE source_url = ? LIMIT 1`, sourceURL).Scan(&id)
+    ref := referenceFromURL(sourceURL)
+    err := db.QueryRow(`
+        SELECT id FROM products
+        WHERE source_url IN (?, ?) OR (? <> '' AND retailer = ? AND reference = ?)
+        LIMIT 1
+    `, sourceURL, canonicalURL(sourceURL), ref, retailer, ref).Scan(&id)
     if err == sql.ErrNoRows {
         return false, nil
     }
@@ -64,6 +92,15 @@ func RunMigrations(db *sql.DB) error {
             UNIQUE KEY uniq_product_doc (product_id, url),
             FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
         ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;`,
+        `CREATE TABLE IF NOT EXISTS product_attributes (
+            id BIGINT AUTO_INCREMENT PRIMARY KEY,
+            product_id BIGINT NOT NULL,
+            name VARCHAR(255) NOT NULL,
+            value VARCHAR(512) NOT NULL,
+            position INT NULL,
+            UNIQUE KEY uniq_product_attribute (product_id, name),
+            FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+        ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;`,
         `CREATE TABLE IF NOT EXISTS product_availability (
             id BIGINT AUTO_INCREMENT PRIMARY KEY,
             product_id BIGINT NOT NULL,
@@ -95,26 +132,187 @@ func RunMigrations(db *sql.DB) error {
             INDEX idx_avail_hist_product_time (product_id, recorded_at),
             FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
         ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;`,
+        `CREATE TABLE IF NOT EXISTS crawl_locks (
+            name VARCHAR(64) PRIMARY KEY,
+            owner VARCHAR(255) NOT NULL,
+            acquired_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            expires_at TIMESTAMP NOT NULL
+        ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;`,
+        `CREATE TABLE IF NOT EXISTS product_matches (
+            id BIGINT AUTO_INCREMENT PRIMARY KEY,
+            product_a_id BIGINT NOT NULL,
+            product_b_id BIGINT NOT NULL,
+            method VARCHAR(32) NOT NULL,
+            confidence DECIMAL(4,3) NOT NULL,
+            status VARCHAR(16) NOT NULL DEFAULT 'pending',
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+            reviewed_at TIMESTAMP NULL,
+            UNIQUE KEY uniq_match_pair (product_a_id, product_b_id),
+            INDEX idx_matches_status (status, confidence),
+            FOREIGN KEY (product_a_id) REFERENCES products(id) ON DELETE CASCADE,
+            FOREIGN KEY (product_b_id) REFERENCES products(id) ON DELETE CASCADE
+        ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;`,
+        `CREATE TABLE IF NOT EXISTS price_quarantine (
+            id BIGINT AUTO_INCREMENT PRIMARY KEY,
+            product_id BIGINT NOT NULL,
+            run_id VARCHAR(64) NOT NULL,
+            url VARCHAR(512) NOT NULL,
+            price DECIMAL(12,2) NOT NULL,
+            price_text VARCHAR(64) NULL,
+            previous_price DECIMAL(12,2) NULL,
+            pct_change DECIMAL(8,2) NULL,
+            z_score DECIMAL(8,2) NULL,
+            reason VARCHAR(255) NOT NULL,
+            status VARCHAR(16) NOT NULL DEFAULT 'pending',
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            resolved_at TIMESTAMP NULL,
+            INDEX idx_quarantine_product_status (product_id, status),
+            FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+        ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;`,
+        `CREATE TABLE IF NOT EXISTS product_validation_issues (
+            id BIGINT AUTO_INCREMENT PRIMARY KEY,
+            run_id VARCHAR(64) NOT NULL,
+            url VARCHAR(512) NOT NULL,
+            reference VARCHAR(32) NULL,
+            rule_name VARCHAR(64) NOT NULL,
+            severity VARCHAR(16) NOT NULL,
+            message TEXT,
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            INDEX idx_validation_run (run_id, severity),
+            INDEX idx_validation_rule (rule_name)
+        ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;`,
+        `CREATE TABLE IF NOT EXISTS crawl_failures (
+            id BIGINT AUTO_INCREMENT PRIMARY KEY,
+            run_id VARCHAR(64) NOT NULL,
+            url VARCHAR(512) NOT NULL,
+            reference VARCHAR(32) NULL,
+            failure_class VARCHAR(32) NOT NULL,
+            error TEXT,
+            artifacts_dir VARCHAR(512) NULL,
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            INDEX idx_failures_run (run_id),
+            INDEX idx_failures_url (url)
+        ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;`,
     }
     for _, stmt := range stmts {
         if _, err := db.Exec(stmt); err != nil {
             return err
         }
     }
+    // columns added after the initial schema; CREATE TABLE IF NOT EXISTS
+    // leaves existing tables untouched, so add them one by one
+    columns := []struct{ table, column, ddl string }{
+        {"products", "status", "VARCHAR(32) NOT NULL DEFAULT 'active'"},
+        {"products", "discontinued_at", "TIMESTAMP NULL"},
+        {"products", "successor_url", "VARCHAR(512) NULL"},
+        {"products", "last_seen_at", "TIMESTAMP NULL"},
+        {"products", "reference", "VARCHAR(32) NULL"},
+        {"products", "retailer", "VARCHAR(32) NOT NULL DEFAULT 'obramat'"},
+        {"products", "ean", "VARCHAR(14) NULL"},
+        {"products", "brand", "VARCHAR(64) NULL"},
+        {"products", "model", "VARCHAR(64) NULL"},
+        {"products", "category", "VARCHAR(255) NULL"},
+        {"product_availability", "status", "VARCHAR(32) NULL"},
+        {"product_availability", "quantity_is_lower_bound", "BOOLEAN NOT NULL DEFAULT FALSE"},
+        {"product_availability", "click_and_collect", "BOOLEAN NOT NULL DEFAULT FALSE"},
+        {"product_availability", "home_delivery", "BOOLEAN NOT NULL DEFAULT FALSE"},
+        {"product_availability_history", "status", "VARCHAR(32) NULL"},
+        {"product_availability_history", "quantity_is_lower_bound", "BOOLEAN NOT NULL DEFAULT FALSE"},
+        {"product_availability_history", "click_and_collect", "BOOLEAN NOT NULL DEFAULT FALSE"},
+        {"product_availability_history", "home_delivery", "BOOLEAN NOT NULL DEFAULT FALSE"},
+    }
+    for _, c := range columns {
+        if err := addColumnIfMissing(db, c.table, c.column, c.ddl); err != nil {
+            return err
+        }
+    }
+    // not unique: rows created before references were tracked may still be
+    // duplicates until -merge-duplicates folds them together
+    if err := addIndexIfMissing(db, "products", "idx_products_reference", "reference"); err != nil {
+        return err
+    }
+    if err := addIndexIfMissing(db, "products", "idx_products_ean", "ean"); err != nil {
+        return err
+    }
     return nil
 }
 
+func addIndexIfMissing(db *sql.DB, table, index, columns string) error {
+    var n int
+    err := db.QueryRow(`
+        SELECT COUNT(*) FROM information_schema.STATISTICS
+        WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND INDEX_NAME = ?
+    `, table, index).Scan(&n)
+    if err != nil || n > 0 {
+        return err
+    }
+    _, err = db.Exec("CREATE INDEX " + index + " ON " + table + " (" + columns + ")")
+    return err
+}
+
+func addColumnIfMissing(db *sql.DB, table, column, ddl string) error {
+    var n int
+    err := db.QueryRow(`
+        SELECT COUNT(*) FROM information_schema.COLUMNS
+        WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND COLUMN_NAME = ?
+    `, table, column).Scan(&n)
+    if err != nil || n > 0 {
+        return err
+    }
+    _, err = db.Exec("ALTER TABLE " + table + " ADD COLUMN " + column + " " + ddl)
+    return err
+}
+
 func UpsertProduct(db *sql.DB, p productData) (int64, error) {
+    // a known reference identifies the row even if it was stored under
+    // another URL; the stored source_url is left alone to avoid colliding
+    // with unmerged duplicates
+    retailer := p.Retailer
+    if retailer == "" {
+        retailer = "obramat"
+    }
+    if p.Reference != "" {
+        var existing int64
+        err := db.QueryRow(`SELECT id FROM products WHERE retailer = ? AND reference = ? ORDER BY id LIMIT 1`, retailer, p.Reference).Scan(&existing)
+        if err == nil {
+            _, err = db.Exec(`
+                UPDATE products SET
+                    title=?, description=?,
+                    price=IF(?, price, ?), price_text=IF(?, price_text, ?), currency=?,
+                    ean=COALESCE(?, ean), brand=COALESCE(?, brand), model=COALESCE(?, model),
+                    category=COALESCE(?, category),
+                    status='active', discontinued_at=NULL, successor_url=NULL, last_seen_at=NOW()
+                WHERE id = ?
+            `, p.Title, p.Description, p.HoldPrice, p.PriceNumeric, p.HoldPrice, p.PriceText, p.Currency,
+                nullString(p.EAN), nullString(p.Brand), nullString(p.Model), nullString(p.Category), existing)
+            return existing, err
+        }
+        if err != sql.ErrNoRows {
+            return 0, err
+        }
+    }
+
     res, err := db.Exec(`
-        INSERT INTO products (source_url, title, description, price, price_text, currency)
-        VALUES (?, ?, ?, ?, ?, ?)
+        INSERT INTO products (source_url, retailer, reference, ean, brand, model, category, title, description, price, price_text, currency, status, last_seen_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 'active', NOW())
         ON DUPLICATE KEY UPDATE
+            reference=COALESCE(VALUES(reference), reference),
+            ean=COALESCE(VALUES(ean), ean),
+            brand=COALESCE(VALUES(brand), brand),
+            model=COALESCE(VALUES(model), model),
+            category=COALESCE(VALUES(category), category),
             title=VALUES(title),
             description=VALUES(description),
-            price=VALUES(price),
-            price_text=VALUES(price_text),
-            currency=VALUES(currency)
-    `, p.SourceURL, p.Title, p.Description, p.PriceNumeric, p.PriceText, p.Currency)
+            price=IF(?, price, VALUES(price)),
+            price_text=IF(?, price_text, VALUES(price_text)),
+            currency=VALUES(currency),
+            status='active',
+            discontinued_at=NULL,
+            successor_url=NULL,
+            last_seen_at=NOW()
+    `, p.SourceURL, retailer, nullString(p.Reference), nullString(p.EAN), nullString(p.Brand), nullString(p.Model),
+        nullString(p.Category), p.Title, p.Description, p.PriceNumeric, p.PriceText, p.Currency, p.HoldPrice, p.HoldPrice)
     if err != nil {
         return 0, err
     }
@@ -130,6 +328,28 @@ func UpsertProduct(db *sql.DB, p productData) (int64, error) {
     return existing, nil
 }
 
+// MarkProductGone records that the product at sourceURL is no longer sold.
+// discontinued_at keeps the first time it was seen gone.
+func MarkProductGone(db *sql.DB, retailer, sourceURL string, status productStatus) error {
+    ref := referenceFromURL(sourceURL)
+    _, err := db.Exec(`
+        UPDATE products
+        SET status = ?, discontinued_at = COALESCE(discontinued_at, NOW())
+        WHERE source_url IN (?, ?) OR (? <> '' AND retailer = ? AND reference = ?)
+    `, string(status), sourceURL, canonicalURL(sourceURL), ref, retailer, ref)
+    return err
+}
+
+// MarkProductRedirected records that sourceURL now redirects to successorURL.
+func MarkProductRedirected(db *sql.DB, sourceURL, successorURL string) error {
+    _, err := db.Exec(`
+        UPDATE products
+        SET status = ?, successor_url = ?, discontinued_at = COALESCE(discontinued_at, NOW())
+        WHERE source_url IN (?, ?) AND (reference IS NULL OR reference <> ?)
+    `, string(productRedirected), successorURL, sourceURL, canonicalURL(sourceURL), referenceFromURL(successorURL))
+    return err
+}
+
 func InsertPriceHistory(db *sql.DB, productID int64, p productData) error {
     _, err := db.Exec(`
         INSERT INTO product_price_history (product_id, price, price_text, currency)
@@ -147,6 +367,20 @@ func InsertPriceHistory(db *sql.DB, productID int64, p productData) error {
     return nil
 }
 
+// UpsertAttributes stores the product's specifications; a name seen again
+// takes the newly scraped value.
+func UpsertAttributes(db *sql.DB, productID int64, attrs []productAttribute) error {
+    for idx, a := range attrs {
+        if _, err := db.Exec(`
+            INSERT INTO product_attributes (product_id, name, value, position) VALUES (?, ?, ?, ?)
+            ON DUPLICATE KEY UPDATE value = VALUES(value), position = VALUES(position)
+        `, productID, a.Name, a.Value, idx); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
  func UpsertTechDoc(db *sql.DB, productID int64, url string) error {
     if url == "" {
         return nil
@@ -155,37 +389,60 @@ func InsertPriceHistory(db *sql.DB, productID int64, p productData) error {
     return err
 }
 
-func UpsertAvailability(db *sql.DB, productID int64, city, store, availText, qty string) error {
-    // best-effort parse quantity to int
-    var stock *int
-    if qty != "" {
-        if n, err := strconv.Atoi(qty); err == nil {
-            stock = &n
-        }
-    }
-
+func UpsertAvailability(db *sql.DB, productID int64, city, store string, a availability) error {
     // replace semantics for this store
     if _, err := db.Exec(`DELETE FROM product_availability WHERE product_id = ? AND store_city = ? AND store_name = ?`, productID, city, store); err != nil {
         return err
     }
     _, err := db.Exec(`
-        INSERT INTO product_availability (product_id, store_city, store_name, availability_text, stock)
-        VALUES (?, ?, ?, ?, ?)
-    `, productID, city, store, availText, stock)
+        INSERT INTO product_availability (product_id, store_city, store_name, availability_text, stock,
+            status, quantity_is_lower_bound, click_and_collect, home_delivery)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `, productID, city, store, a.Raw, a.quantityOrNil(), string(a.Status), a.QuantityIsLowerBound, a.ClickAndCollect, a.HomeDelivery)
     return err
 }
 
-func InsertAvailabilityHistory(db *sql.DB, productID int64, city, store, availText, qty string) error {
-    var stock *int
-    if qty != "" {
-        if n, err := strconv.Atoi(qty); err == nil {
-            stock = &n
-        }
-    }
+func InsertAvailabilityHistory(db *sql.DB, productID int64, city, store string, a availability) error {
     _, err := db.Exec(`
-        INSERT INTO product_availability_history (product_id, store_city, store_name, availability_text, stock)
-        VALUES (?, ?, ?, ?, ?)
-    `, productID, city, store, availText, stock)
+        INSERT INTO product_availability_history (product_id, store_city, store_name, availability_text, stock,
+            status, quantity_is_lower_bound, click_and_collect, home_delivery)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `, productID, city, store, a.Raw, a.quantityOrNil(), string(a.Status), a.QuantityIsLowerBound, a.ClickAndCollect, a.HomeDelivery)
+    return err
+}
+
+// AcquireCrawlLock takes the named lock row for owner unless another owner
+// holds an unexpired lock. It reports whether owner now holds the lock.
+func AcquireCrawlLock(db *sql.DB, name, owner string, ttl time.Duration) (bool, error) {
+    secs := int(ttl.Seconds())
+    // expires_at must be assigned last: the earlier IF()s read its old value.
+    _, err := db.Exec(`
+        INSERT INTO crawl_locks (name, owner, acquired_at, expires_at)
+        VALUES (?, ?, NOW(), NOW() + INTERVAL ? SECOND)
+        ON DUPLICATE KEY UPDATE
+            owner=IF(expires_at < NOW(), VALUES(owner), owner),
+            acquired_at=IF(expires_at < NOW(), VALUES(acquired_at), acquired_at),
+            expires_at=IF(expires_at < NOW(), VALUES(expires_at), expires_at)
+    `, name, owner, secs)
+    if err != nil {
+        return false, err
+    }
+    var holder string
+    if err := db.QueryRow(`SELECT owner FROM crawl_locks WHERE name = ?`, name).Scan(&holder); err != nil {
+        return false, err
+    }
+    return holder == owner, nil
+}
+
+// RefreshCrawlLock extends a held lock by ttl from now.
+func RefreshCrawlLock(db *sql.DB, name, owner string, ttl time.Duration) error {
+    _, err := db.Exec(`UPDATE crawl_locks SET expires_at = NOW() + INTERVAL ? SECOND WHERE name = ? AND owner = ?`,
+        int(ttl.Seconds()), name, owner)
+    return err
+}
+
+func ReleaseCrawlLock(db *sql.DB, name, owner string) error {
+    _, err := db.Exec(`DELETE FROM crawl_locks WHERE name = ? AND owner = ?`, name, owner)
     return err
 }
 
@@ -203,3 +460,42 @@ func parsePrice(priceText string) float64 {
     }
     return f
 }
+
+// crawlFailure is one failed URL of a run, stored in crawl_failures.
+type crawlFailure struct {
+    RunID        string
+    URL          string
+    Reference    string
+    Class        string
+    Error        string
+    // ArtifactsDir holds the screenshot, DOM, console log and HAR, if saved.
+    ArtifactsDir string
+}
+
+func InsertCrawlFailure(db *sql.DB, f crawlFailure) error {
+    _, err := db.Exec(`
+        INSERT INTO crawl_failures (run_id, url, reference, failure_class, error, artifacts_dir)
+        VALUES (?, ?, ?, ?, ?, ?)
+    `, f.RunID, f.URL, nullString(f.Reference), f.Class, f.Error, nullString(f.ArtifactsDir))
+    return err
+}
+
+func InsertValidationIssues(db *sql.DB, runID, url, reference string, issues []validationIssue) error {
+    for _, i := range issues {
+        if _, err := db.Exec(`
+            INSERT INTO product_validation_issues (run_id, url, reference, rule_name, severity, message)
+            VALUES (?, ?, ?, ?, ?, ?)
+        `, runID, url, nullString(reference), i.Rule, string(i.Severity), i.Message); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// nullString maps "" to NULL for optional columns.
+func nullString(s string) any {
+    if s == "" {
+        return nil
+    }
+    return s
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/chromedp/chromedp"
+)
+
+// referenceRe captures the Obramat product reference at the end of a product
+// URL, e.g. ...-25022742.html.
+var referenceRe = regexp.MustCompile(`-(\d{6,})\.html$`)
+
+// skuRe accepts page SKUs that look like Obramat references.
+var skuRe = regexp.MustCompile(`^\d{6,}$`)
+
+// referenceFromURL returns the retailer reference embedded in a product URL,
+// or "" if there is none.
+func referenceFromURL(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return ""
+	}
+	if m := referenceRe.FindStringSubmatch(strings.TrimSuffix(u.Path, "/")); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// canonicalURL normalises a product URL so that the same page reached with
+// tracking parameters, fragments, http or a mixed-case host maps to one key.
+func canonicalURL(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return strings.TrimSpace(raw)
+	}
+	u.Scheme = "https"
+	u.Host = strings.TrimPrefix(strings.ToLower(u.Host), "m.")
+	if !strings.HasPrefix(u.Host, "www.") && strings.Count(u.Host, ".") == 1 {
+		u.Host = "www." + u.Host
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	u.RawQuery = ""
+	u.Fragment = ""
+	u.User = nil
+	return u.String()
+}
+
+// pageIdentity is what a product page says about itself.
+type pageIdentity struct {
+	Canonical  string             `json:"canonical"`
+	SKU        string             `json:"sku"`
+	GTIN       string             `json:"gtin"`
+	Brand      string             `json:"brand"`
+	Attributes []productAttribute `json:"attributes"`
+	// Breadcrumb lists the JSON-LD BreadcrumbList names in order
+	Breadcrumb []string `json:"breadcrumb"`
+}
+
+// productAttribute is one specification of a product, a schema.org
+// PropertyValue.
+type productAttribute struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// readPageIdentity reads the canonical link, the JSON-LD Product SKU, GTIN,
+// brand and additionalProperty specifications, and the BreadcrumbList.
+func readPageIdentity(ctx context.Context) (pageIdentity, error) {
+	var id pageIdentity
+	err := chromedp.Run(ctx, chromedp.Evaluate(`
+		(function() {
+			const link = document.querySelector('link[rel="canonical"]');
+			let sku = '', gtin = '', brand = '', attributes = [], breadcrumb = [];
+			for (const s of document.querySelectorAll('script[type="application/ld+json"]')) {
+				try {
+					const d = JSON.parse(s.textContent);
+					const items = Array.isArray(d) ? d : (d['@graph'] || [d]);
+					for (const it of items) {
+						if (it && it['@type'] === 'BreadcrumbList' && !breadcrumb.length) {
+							breadcrumb = [].concat(it.itemListElement || [])
+								.sort((a, b) => (a.position || 0) - (b.position || 0))
+								.map(e => String(e.name || (e.item && e.item.name) || '').trim())
+								.filter(n => n);
+						}
+						if (it && it['@type'] === 'Product' && (it.sku || it.productID) && !sku) {
+							sku = String(it.sku || it.productID);
+							gtin = String(it.gtin13 || it.gtin || it.gtin14 || it.gtin12 || it.gtin8 || '');
+							const b = it.brand;
+							brand = b ? String(typeof b === 'object' ? (b.name || '') : b) : '';
+							for (const p of [].concat(it.additionalProperty || [])) {
+								const name = p && p.name != null ? String(p.name).trim() : '';
+								const value = p && p.value != null ? String(p.value).trim() : '';
+								if (name && value && !attributes.some(a => a.name === name.slice(0, 255))) {
+									attributes.push({name: name.slice(0, 255), value: value.slice(0, 512)});
+								}
+							}
+						}
+					}
+				} catch (e) {}
+			}
+			return {canonical: link ? link.href : '', sku: sku, gtin: gtin, brand: brand, attributes: attributes, breadcrumb: breadcrumb};
+		})();
+	`, &id))
+	return id, err
+}
+
+// resolveIdentity picks the canonical URL and reference for a loaded page.
+// The page's own canonical link wins when it names the same product.
+func resolveIdentity(pageURL string, id pageIdentity) (canonical, reference string) {
+	canonical = canonicalURL(pageURL)
+	reference = referenceFromURL(pageURL)
+	if id.Canonical != "" && isProductURL(id.Canonical) {
+		ref := referenceFromURL(id.Canonical)
+		if reference == "" || ref == reference {
+			canonical = canonicalURL(id.Canonical)
+			if reference == "" {
+				reference = ref
+			}
+		}
+	}
+	if reference == "" && skuRe.MatchString(strings.TrimSpace(id.SKU)) {
+		reference = strings.TrimSpace(id.SKU)
+	}
+	return canonical, reference
+}
+
+// breadcrumbCategory joins a page breadcrumb into a category path, leaving
+// out the home page and the product itself, e.g. "Herramientas > Taladros".
+func breadcrumbCategory(crumbs []string, title string) string {
+	var path []string
+	for i, c := range crumbs {
+		c = strings.Join(strings.Fields(c), " ")
+		if i == 0 && (strings.EqualFold(c, "inicio") || strings.EqualFold(c, "home") || strings.EqualFold(c, "obramat")) {
+			continue
+		}
+		if i == len(crumbs)-1 && strings.EqualFold(c, strings.Join(strings.Fields(title), " ")) {
+			continue
+		}
+		path = append(path, c)
+	}
+	// products.category holds 255 characters; drop the deepest crumbs first
+	for len(path) > 1 && len([]rune(strings.Join(path, " > "))) > 255 {
+		path = path[:len(path)-1]
+	}
+	s := strings.Join(path, " > ")
+	if r := []rune(s); len(r) > 255 {
+		s = string(r[:255])
+	}
+	return s
+}
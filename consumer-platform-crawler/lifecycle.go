@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/chromedp/chromedp"
+)
+
+// productStatus is the lifecycle state stored in products.status.
+type productStatus string
+
+const (
+	productActive       productStatus = "active"
+	productNotFound     productStatus = "not_found"
+	productRedirected   productStatus = "redirected"
+	productDiscontinued productStatus = "discontinued"
+)
+
+// goneError reports that a product page no longer exists or no longer sells
+// the product. It is an expected outcome, not a crawl failure.
+type goneError struct {
+	URL    string
+	Status productStatus
+	Reason string
+}
+
+func (e *goneError) Error() string {
+	return fmt.Sprintf("product %s (%s): %s", e.Status, e.URL, e.Reason)
+}
+
+// unavailableMarkers are lower-cased page texts shown when Obramat keeps the
+// URL alive but no longer sells the product.
+var unavailableMarkers = []string{
+	"producto no disponible",
+	"este producto ya no está disponible",
+	"este producto ya no se comercializa",
+	"producto descatalogado",
+	"producte no disponible",
+	"aquest producte ja no està disponible",
+}
+
+// isProductURL reports whether u points to an Obramat product detail page
+// rather than a category, search or home page.
+func isProductURL(u string) bool {
+	p, err := url.Parse(u)
+	if err != nil {
+		return false
+	}
+	path := strings.TrimSuffix(p.Path, "/")
+	return strings.HasPrefix(path, "/productos/") && strings.HasSuffix(path, ".html")
+}
+
+// samePage compares two URLs ignoring query string and fragment.
+func samePage(a, b string) bool {
+	pa, errA := url.Parse(a)
+	pb, errB := url.Parse(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return strings.EqualFold(pa.Host, pb.Host) && strings.TrimSuffix(pa.Path, "/") == strings.TrimSuffix(pb.Path, "/")
+}
+
+// checkLifecycle classifies the page loaded for requested. It returns a
+// *goneError for 404s, redirects away from product pages and unavailable
+// products; successor is set when the URL redirected to a different product.
+func checkLifecycle(ctx context.Context, requested string, status int64) (successor string, err error) {
+	if status == 404 || status == 410 {
+		return "", &goneError{URL: requested, Status: productNotFound, Reason: fmt.Sprintf("http %d", status)}
+	}
+	var location string
+	if err := chromedp.Run(ctx, chromedp.Location(&location)); err != nil {
+		return "", nil
+	}
+	if location == "" || samePage(location, requested) {
+		return "", nil
+	}
+	if isProductURL(location) {
+		return location, nil
+	}
+	return "", &goneError{URL: requested, Status: productDiscontinued, Reason: "redirected to " + location}
+}
+
+// checkUnavailable looks for "no longer available" messages on a loaded page.
+// It is only consulted once the price is missing to avoid false positives from
+// per-channel notices.
+func checkUnavailable(ctx context.Context, pageURL string) error {
+	var text string
+	if err := chromedp.Run(ctx, chromedp.Evaluate(`document.body ? document.body.innerText.slice(0, 50000) : ''`, &text)); err != nil {
+		return nil
+	}
+	t := strings.ToLower(text)
+	for _, m := range unavailableMarkers {
+		if strings.Contains(t, m) {
+			return &goneError{URL: pageURL, Status: productDiscontinued, Reason: fmt.Sprintf("page says %q", m)}
+		}
+	}
+	return nil
+}
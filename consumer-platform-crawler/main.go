@@ -5,262 +5,203 @@ import (
 	"database/sql"
 	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
-	"path/filepath"
-	"strings"
-	"time"
-
-	"github.com/chromedp/chromedp"
+	"os/signal"
+	"syscall"
 )
 
-// saveSnapshot captures the current page HTML into snapshotN/page.html.
-func saveSnapshot(ctx context.Context, idx int) error {
-	var html string
-	if err := chromedp.Run(ctx, chromedp.OuterHTML("html", &html, chromedp.ByQuery)); err != nil {
-		return fmt.Errorf("snapshot %d capture: %w", idx, err)
-	}
-	dir := fmt.Sprintf("snapshot%d", idx)
-	if err := os.MkdirAll(dir, 0o755); err != nil {
-		return fmt.Errorf("snapshot %d mkdir: %w", idx, err)
-	}
-	path := filepath.Join(dir, "page.html")
-	if err := os.WriteFile(path, []byte(html), 0o644); err != nil {
-		return fmt.Errorf("snapshot %d write: %w", idx, err)
-	}
-	log.Printf("saved %s", path)
-	return nil
-}
-
 func main() {
 	var runMigrate bool
 	var migrateOnly bool
 	var skipExisting bool
+	var serve bool
+	var scheduleFile string
+	var continueRun bool
+	var checkpointPath string
+	var maxBlocks int
+	var rotationFile string
+	var mergeDuplicates bool
+	var matchProducts bool
+	var reviewMatches bool
+	var metricsAddr string
+	var metricsFile string
+	var logFormat string
+	var artifactsDir string
+	var validationFile string
+	var logLevel string
 	flag.BoolVar(&runMigrate, "migrate", false, "run DB migrations before scraping")
 	flag.BoolVar(&migrateOnly, "migrate-only", false, "run DB migrations and exit")
 	flag.BoolVar(&skipExisting, "resume", false, "skip URLs already present in DB (resume mode)")
+	flag.BoolVar(&mergeDuplicates, "merge-duplicates", false, "merge products sharing a retailer reference and exit")
+	flag.BoolVar(&matchProducts, "match-products", false, "match products across retailers by EAN, model and title, then exit")
+	flag.BoolVar(&reviewMatches, "review-matches", false, "interactively confirm or reject pending product matches, then exit")
+	flag.BoolVar(&serve, "serve", false, "run as a daemon, crawling on the cron schedules in -schedule")
+	flag.BoolVar(&continueRun, "continue", false, "continue the interrupted run from its checkpoint")
+	flag.StringVar(&checkpointPath, "checkpoint", "./crawl-checkpoint.json", "checkpoint file written during one-shot runs")
+	flag.IntVar(&maxBlocks, "max-blocks", 5, "stop the crawl after this many consecutive blocked/challenge pages")
+	flag.StringVar(&rotationFile, "rotation", "", "JSON file with proxies and browser fingerprints to rotate per tab (disabled if empty)")
+	flag.StringVar(&metricsAddr, "metrics-addr", "", "serve Prometheus metrics on this address, e.g. :9101 (disabled if empty)")
+	flag.StringVar(&metricsFile, "metrics-file", "", "write Prometheus metrics to this file after every URL, for node_exporter's textfile collector")
+	flag.StringVar(&scheduleFile, "schedule", "./schedule.json", "JSON file with the daemon's scheduled jobs")
+	flag.StringVar(&validationFile, "validation", "", "JSON file overriding data quality rule severities (built-in defaults if empty)")
+	flag.StringVar(&artifactsDir, "artifacts-dir", "./artifacts", "directory for screenshots, DOM, console logs and HARs of failed URLs (disabled if empty)")
+	flag.StringVar(&logFormat, "log-format", "text", "log output format: text or json")
+	flag.StringVar(&logLevel, "log-level", "info", "minimum log level: debug, info, warn or error")
 	flag.Parse()
+	if err := setupLogging(logFormat, logLevel); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
 
 	dsn := "root:root@tcp(localhost:3306)/obramat?parseTime=true&charset=utf8mb4&loc=Local"
 	db, err := sql.Open("mysql", dsn)
 	if err != nil {
-		log.Fatalf("db open failed: %v", err)
+		fatal("db open failed", "err", err)
 	}
 	defer db.Close()
 	if err := db.Ping(); err != nil {
-		log.Fatalf("db ping failed: %v", err)
+		fatal("db ping failed", "err", err)
 	}
 	if runMigrate || migrateOnly {
 		if err := RunMigrations(db); err != nil {
-			log.Fatalf("migrations failed: %v", err)
+			fatal("migrations failed", "err", err)
 		}
-		log.Printf("migrations completed")
+		slog.Info("migrations completed")
 		if migrateOnly {
 			return
 		}
 	}
 
-	var urlList []string
-	// read from ./product-urls.txt
-	data, err := os.ReadFile("./product-urls.txt")
-	if err != nil {
-		log.Fatalf("failed to read product-urls.txt: %v", err)
-	}
-	for _, line := range strings.Split(string(data), "\n") {
-		line = strings.TrimSpace(line)
-		if line != "" {
-			urlList = append(urlList, line)
+	if mergeDuplicates {
+		n, err := MergeDuplicateProducts(db)
+		if err != nil {
+			fatal("merge failed", "err", err)
 		}
+		slog.Info("merged duplicate products", "count", n)
+		return
 	}
-	if len(urlList) == 0 {
-		log.Fatalf("no URLs found in product-urls.txt")
-	}
-
-	// Headless browser context with a timeout to avoid hanging.
-	allocCtx, _ := chromedp.NewExecAllocator(context.Background(),
-		append(chromedp.DefaultExecAllocatorOptions[:],
-			chromedp.Flag("headless", false), // show browser; headful often passes more checks
-			chromedp.UserDataDir(os.ExpandEnv(`./chrome-profile`)),
-			chromedp.Flag("disable-blink-features", "AutomationControlled"),
-			chromedp.Flag("start-maximized", true),
-			chromedp.UserAgent("Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"),
-		)...,
-	)
-	ctx, cancel := chromedp.NewContext(allocCtx)
-	defer cancel()
 
-	for _, url := range urlList {
-		if skipExisting {
-			exists, err := productExists(db, url)
-			if err != nil {
-				log.Printf("existence check failed (%s): %v", url, err)
-				continue
-			}
-			if exists {
-				log.Printf("skip existing %s", url)
-				continue
-			}
-		}
-
-		perURLCtx, perURLCancel := chromedp.NewContext(ctx)       // new tab per URL
-		perURLCtx, perURLTimeoutCancel := context.WithTimeout(perURLCtx, 45*time.Second)
-		log.Printf("processing %s", url)
-
-		var priceText string
-		var titleText string
-		var descriptionText string
-		var stockText string
-		var carouselImages []string
-		var techDocURL string
-
-		if err := chromedp.Run(perURLCtx, chromedp.Navigate(url)); err != nil {
-			perURLTimeoutCancel(); perURLCancel()
-			log.Printf("navigate failed (%s): %v", url, err)
-			continue
-		}
-		if err := chromedp.Run(perURLCtx, chromedp.Sleep(2*time.Second)); err != nil {
-			perURLTimeoutCancel(); perURLCancel()
-			log.Printf("sleep failed (%s): %v", url, err)
-			continue
+	if matchProducts {
+		n, err := MatchProducts(db)
+		if err != nil {
+			fatal("matching failed", "err", err)
 		}
-
-		if err := chromedp.Run(perURLCtx, chromedp.Evaluate(`
-			Array.from(new Set(
-				Array.from(document.querySelectorAll('.kl-swiper img')).map(img => {
-					let url = img.src || img.getAttribute('data-src') || '';
-					return url.split('?')[0];
-				}).filter(url => url.length > 0)
-			));
-		`, &carouselImages)); err != nil {
-			log.Printf("carousel extraction warning (%s): %v", url, err)
+		slog.Info("matched products across retailers", "matches", n)
+		return
+	}
+	if reviewMatches {
+		if err := ReviewMatches(db, os.Stdin, os.Stdout); err != nil {
+			fatal("match review failed", "err", err)
 		}
+		return
+	}
 
-		if err := chromedp.Run(perURLCtx, chromedp.Evaluate(`
-			(function() {
-				const link = document.querySelector('a[href*=".pdf"]');
-				if (link) {
-					return link.href.split('?')[0];
-				}
-				return '';
-			})();
-		`, &techDocURL)); err != nil {
-			log.Printf("tech doc extraction warning (%s): %v", url, err)
+	var rot *rotator
+	if rotationFile != "" {
+		if rot, err = loadRotator(rotationFile); err != nil {
+			fatal("rotation config load failed", "path", rotationFile, "err", err)
 		}
+	}
 
-		if err := chromedp.Run(perURLCtx,
-			chromedp.WaitVisible(`.m-price.-main .m-price__line`, chromedp.ByQuery),
-			chromedp.Text(`.m-price.-main .m-price__line`, &priceText, chromedp.ByQuery),
-		); err != nil {
-			perURLTimeoutCancel(); perURLCancel()
-			log.Printf("price read failed (%s): %v", url, err)
-			continue
+	var rules validationRules
+	if validationFile != "" {
+		if rules, err = loadValidationRules(validationFile); err != nil {
+			fatal("validation config load failed", "path", validationFile, "err", err)
 		}
+	}
 
-		if err := chromedp.Run(perURLCtx,
-			chromedp.Text(`h1.l-product-detail-presentation__title`, &titleText, chromedp.ByQuery),
-		); err != nil {
-			perURLTimeoutCancel(); perURLCancel()
-			log.Printf("title read failed (%s): %v", url, err)
-			continue
+	if serve {
+		jobs, err := loadSchedule(scheduleFile)
+		if err != nil {
+			fatal("schedule load failed", "path", scheduleFile, "err", err)
 		}
-
-		if err := chromedp.Run(perURLCtx,
-			chromedp.AttributeValue(`meta[name="description"]`, "content", &descriptionText, nil, chromedp.ByQuery),
-		); err != nil {
-			perURLTimeoutCancel(); perURLCancel()
-			log.Printf("description read failed (%s): %v", url, err)
-			continue
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if metricsAddr != "" {
+			serveMetrics(ctx, metricsAddr)
 		}
-
-		if err := chromedp.Run(perURLCtx,
-			chromedp.Click(`button.o-availabilities__actionButton.js-choose-store-in_store.js-cdl`, chromedp.ByQuery),
-		); err != nil {
-			perURLTimeoutCancel(); perURLCancel()
-			log.Printf("availability click failed (%s): %v", url, err)
-			continue
+		base := crawlOptions{
+			Rotator:              rot,
+			ArtifactsDir:         artifactsDir,
+			Validation:           rules,
+			MaxConsecutiveBlocks: maxBlocks,
+			MetricsFile:          metricsFile,
 		}
-		if err := chromedp.Run(perURLCtx, chromedp.Sleep(2*time.Second)); err != nil {
-			perURLTimeoutCancel(); perURLCancel()
-			log.Printf("post-click sleep failed (%s): %v", url, err)
-			continue
+		if err := runDaemon(ctx, db, jobs, base); err != nil {
+			fatal("daemon failed", "err", err)
 		}
+		return
+	}
 
-		if err := chromedp.Run(perURLCtx,
-			chromedp.WaitVisible(`#contextLayerSearchInput--998`, chromedp.ByID),
-		); err != nil {
-			perURLTimeoutCancel(); perURLCancel()
-			log.Printf("search input wait failed (%s): %v", url, err)
-			continue
-		}
+	urlsFile := "./product-urls.txt"
+	urlList, err := readURLList(urlsFile)
+	if err != nil {
+		fatal("failed to read URL list", "path", urlsFile, "err", err)
+	}
+	if len(urlList) == 0 {
+		fatal("no URLs found", "path", urlsFile)
+	}
 
-		if err := chromedp.Run(perURLCtx,
-			chromedp.SendKeys(`#contextLayerSearchInput--998`, "08911, Badalona, Barcelona, España"+"\n", chromedp.ByID),
-		); err != nil {
-			perURLTimeoutCancel(); perURLCancel()
-			log.Printf("send keys failed (%s): %v", url, err)
-			continue
+	opts := crawlOptions{
+		SkipExisting:         skipExisting,
+		RunID:                newRunID(),
+		URLsFile:             urlsFile,
+		CheckpointPath:       checkpointPath,
+		MaxConsecutiveBlocks: maxBlocks,
+		Rotator:              rot,
+		MetricsFile:          metricsFile,
+		ArtifactsDir:         artifactsDir,
+		Validation:           rules,
+	}
+	if continueRun {
+		cp, err := loadCheckpoint(checkpointPath)
+		if err != nil {
+			fatal("checkpoint load failed", "path", checkpointPath, "err", err)
 		}
-		if err := chromedp.Run(perURLCtx, chromedp.Sleep(2*time.Second)); err != nil {
-			perURLTimeoutCancel(); perURLCancel()
-			log.Printf("post-sendkeys sleep failed (%s): %v", url, err)
-			continue
+		if cp == nil {
+			fatal("no checkpoint found, nothing to continue", "path", checkpointPath)
 		}
-
-		if err := chromedp.Run(perURLCtx,
-			chromedp.WaitVisible(`article[data-store-city="Badalona"] .stock-status_text`, chromedp.ByQuery),
-			chromedp.Text(`article[data-store-city="Badalona"] .stock-status_text`, &stockText, chromedp.ByQuery),
-		); err != nil {
-			perURLTimeoutCancel(); perURLCancel()
-			log.Printf("stock read failed (%s): %v", url, err)
-			continue
+		if opts.StartIndex, err = cp.resumeIndex(urlList); err != nil {
+			fatal("cannot continue", "err", err)
 		}
+		opts.RunID = cp.RunID
+		slog.Info("continuing run", "run_id", opts.RunID, "index", opts.StartIndex, "total", len(urlList))
+	}
 
-		perURLTimeoutCancel()
-		perURLCancel()
-
-		priceText = strings.Split(priceText, "\n")[0]
-		stockText = strings.Split(stockText, " ")[0]
-
-		log.Printf("[%s] title: %s", url, strings.TrimSpace(titleText))
-		log.Printf("[%s] price: %s", url, priceText)
-		log.Printf("[%s] availability: %s", url, stockText)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		// Restore default handling so a second Ctrl+C kills the process.
+		stop()
+		slog.Warn("interrupt received, finishing current product (press Ctrl+C again to force quit)", "run_id", opts.RunID)
+	}()
+	if metricsAddr != "" {
+		serveMetrics(ctx, metricsAddr)
+	}
 
-		prod := productData{
-			SourceURL:       url,
-			Title:           strings.TrimSpace(titleText),
-			Description:     strings.TrimSpace(descriptionText),
-			PriceNumeric:    parsePrice(priceText),
-			PriceText:       strings.TrimSpace(priceText),
-			Currency:        "EUR",
-			CarouselImages:  carouselImages,
-			TechDocURL:      strings.TrimSpace(techDocURL),
-			AvailabilityQty: strings.TrimSpace(stockText),
-			AvailabilityRaw: strings.TrimSpace(stockText),
-			StoreCity:       "Badalona",
-			StoreName:       "Obramat Badalona",
-		}
+	// The daemon's jobs share the Chrome profile, so a manual run waits its
+	// turn like any scheduled one.
+	release, acquired, err := holdCrawlLock(db, lockOwner(), slog.With("run_id", opts.RunID))
+	if err != nil {
+		fatal("crawl lock failed", "err", err)
+	}
+	if !acquired {
+		fatal("another crawl holds the lock, try again when it finishes", "lock", crawlLockName)
+	}
+	defer release()
 
-		productID, err := UpsertProduct(db, prod)
-		if err != nil {
-			log.Printf("product Upsert failed (%s): %v", url, err)
-			continue
-		}
-		if err := InsertPriceHistory(db, productID, prod); err != nil {
-			log.Printf("price history insert failed (%s): %v", url, err)
-		}
-		if err := UpsertImages(db, productID, prod.CarouselImages); err != nil {
-			log.Printf("images Upsert failed (%s): %v", url, err)
-		}
-		if err := UpsertTechDoc(db, productID, prod.TechDocURL); err != nil {
-			log.Printf("tech doc Upsert failed (%s): %v", url, err)
-		}
-		if err := UpsertAvailability(db, productID, prod.StoreCity, prod.StoreName, prod.AvailabilityRaw, prod.AvailabilityQty); err != nil {
-			log.Printf("availability Upsert failed (%s): %v", url, err)
-		}
-		if err := InsertAvailabilityHistory(db, productID, prod.StoreCity, prod.StoreName, prod.AvailabilityRaw, prod.AvailabilityQty); err != nil {
-			log.Printf("availability history insert failed (%s): %v", url, err)
+	slog.Info("run started", "run_id", opts.RunID, "urls", len(urlList))
+	if err := crawlURLs(ctx, db, urlList, opts); err != nil {
+		if ctx.Err() != nil {
+			slog.Warn("run interrupted, resume with -continue", "run_id", opts.RunID, "checkpoint", checkpointPath, "err", err)
+			return
 		}
-
-		log.Printf("saved product %d to DB for %s", productID, url)
+		// The circuit breaker opened or every proxy was retired: the run
+		// did not finish, so schedulers and CI must see a failure.
+		release()
+		fatal("run aborted, resume with -continue", "run_id", opts.RunID, "checkpoint", checkpointPath, "err", err)
 	}
+	slog.Info("run completed", "run_id", opts.RunID)
 }
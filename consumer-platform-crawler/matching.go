@@ -0,0 +1,445 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Methods that link two products, from strongest to weakest signal.
+const (
+	matchByEAN         = "ean"
+	matchByModel       = "brand_model"
+	matchByModelFamily = "model_family"
+	matchByTitle       = "title"
+)
+
+// Confidence assigned per method. Title matches scale with similarity.
+const (
+	confidenceEAN         = 1.0
+	confidenceModel       = 0.9
+	confidenceModelFamily = 0.75
+	titleConfidenceWeight = 0.7
+	minTitleSimilarity    = 0.6
+)
+
+// matchStatus is the review state stored in product_matches.status.
+type matchStatus string
+
+const (
+	matchPending   matchStatus = "pending"
+	matchConfirmed matchStatus = "confirmed"
+	matchRejected  matchStatus = "rejected"
+)
+
+// knownBrands are recognised in titles when the page has no structured
+// brand. Multi-word brands are written the way normaliseBrand spells them.
+var knownBrands = []string{
+	"aeg", "bahco", "black+decker", "bosch", "dewalt", "dremel", "einhell", "facom",
+	"festool", "fischer", "greenworks", "hikoki", "hilti", "hitachi", "husqvarna",
+	"irwin", "karcher", "knipex", "makita", "metabo", "milwaukee", "rubi", "ryobi",
+	"stanley", "stihl", "wera", "worx",
+}
+
+var (
+	brandReplacer = strings.NewReplacer("black & decker", "black+decker", "black and decker", "black+decker", "ä", "a")
+	// specRe recognises tokens that are ratings rather than model numbers,
+	// e.g. 18V, 5Ah, 1100W, 2,6J, 125mm.
+	specRe = regexp.MustCompile(`^\d+([.,]\d+)?(v|ah|w|kw|j|mm|cm|m|kg|g|nm|rpm|l|bar|cc)$`)
+	// familyRe splits a full model number (at least three digits) into series
+	// and kit suffix: up to three letters (Z, NXJ), a Makita battery kit
+	// (RFX8) or a DeWalt one (D2, P2). Platform prefixes such as M18 and
+	// voltage-named models such as GSR18V55 have no family.
+	familyRe   = regexp.MustCompile(`^([A-Z]+\d{3,})(?:[A-Z]{1,3}|R[A-Z]{1,2}\d|[DPML]\d)?$`)
+	seriesRe   = regexp.MustCompile(`^[A-Z]{2,4}$`)
+	nonAlnumRe = regexp.MustCompile(`[^a-z0-9+]+`)
+)
+
+// titleStopwords are ignored by titleSimilarity.
+var titleStopwords = map[string]bool{
+	"de": true, "del": true, "con": true, "sin": true, "para": true, "y": true, "e": true,
+	"el": true, "la": true, "los": true, "las": true, "en": true, "a": true, "x": true,
+	"amb": true, "per": true, "i": true, "the": true, "with": true, "and": true, "for": true,
+}
+
+// normaliseBrand lower-cases a brand and folds known spelling variants.
+func normaliseBrand(s string) string {
+	return strings.TrimSpace(brandReplacer.Replace(strings.ToLower(strings.TrimSpace(s))))
+}
+
+// normaliseModel upper-cases a model and drops separators so that
+// "DHP 453-Z" and "DHP453Z" compare equal.
+func normaliseModel(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(s) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// modelFamily strips a trailing kit suffix, so DHP453Z and DHP453RFX8 share
+// the family DHP453. Other models are their own family.
+func modelFamily(model string) string {
+	if m := familyRe.FindStringSubmatch(model); m != nil {
+		return m[1]
+	}
+	return model
+}
+
+// normaliseEAN keeps the digits of a GTIN and returns them only if they form
+// a valid EAN-8, UPC-A, EAN-13 or GTIN-14. UPC-A is widened to EAN-13.
+func normaliseEAN(s string) string {
+	var digits []byte
+	for i := 0; i < len(s); i++ {
+		if s[i] >= '0' && s[i] <= '9' {
+			digits = append(digits, s[i])
+		}
+	}
+	switch len(digits) {
+	case 8, 13, 14:
+	case 12:
+		digits = append([]byte{'0'}, digits...)
+	default:
+		return ""
+	}
+	sum := 0
+	for i := len(digits) - 2; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if (len(digits)-2-i)%2 == 0 {
+			d *= 3
+		}
+		sum += d
+	}
+	if (10-sum%10)%10 != int(digits[len(digits)-1]-'0') {
+		return ""
+	}
+	return string(digits)
+}
+
+// parseBrandModel finds a known brand and the first model-number-like token
+// in a product title, e.g. "Taladro Makita DHP453Z 18V" -> makita, DHP453Z.
+func parseBrandModel(title string) (brand, model string) {
+	lower := brandReplacer.Replace(strings.ToLower(title))
+	fields := strings.Fields(title)
+	for _, w := range strings.Fields(lower) {
+		w = strings.Trim(w, ".,;:()[]\"'")
+		for _, b := range knownBrands {
+			if w == b {
+				brand = b
+				break
+			}
+		}
+		if brand != "" {
+			break
+		}
+	}
+	for i, f := range fields {
+		tok := strings.Trim(f, ".,;:()[]\"'")
+		lowerTok := strings.ToLower(tok)
+		if lowerTok == brand || specRe.MatchString(lowerTok) {
+			continue
+		}
+		if !strings.ContainsAny(tok, "0123456789") || strings.IndexFunc(tok, isLetter) < 0 {
+			continue
+		}
+		m := normaliseModel(tok)
+		if len(m) < 4 {
+			continue
+		}
+		// series names written apart from the number, e.g. "GSR 18V-55"
+		if i > 0 {
+			prev := strings.Trim(fields[i-1], ".,;:()[]\"'")
+			if seriesRe.MatchString(prev) && strings.ToLower(prev) != brand {
+				m = prev + m
+			}
+		}
+		return brand, m
+	}
+	return brand, ""
+}
+
+func isLetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+// titleTokens returns the set of significant words in a title.
+func titleTokens(title string) map[string]bool {
+	t := nonAlnumRe.ReplaceAllString(accentReplacer.Replace(strings.ToLower(title)), " ")
+	tokens := map[string]bool{}
+	for _, w := range strings.Fields(t) {
+		if len(w) < 2 || titleStopwords[w] {
+			continue
+		}
+		tokens[w] = true
+	}
+	return tokens
+}
+
+// titleSimilarity is the Dice coefficient of two token sets, in [0, 1].
+func titleSimilarity(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for w := range a {
+		if b[w] {
+			shared++
+		}
+	}
+	return 2 * float64(shared) / float64(len(a)+len(b))
+}
+
+// matchCandidate is a product as seen by the matcher.
+type matchCandidate struct {
+	ID       int64
+	Retailer string
+	EAN      string
+	Brand    string
+	Model    string
+	Title    string
+	tokens   map[string]bool
+}
+
+// productMatch links two products of different retailers. A is always the
+// lower id so that a pair is stored once.
+type productMatch struct {
+	A, B       int64
+	Method     string
+	Confidence float64
+}
+
+// findMatches groups candidates by EAN, brand+model, brand+model family and,
+// within a brand, title similarity. Only pairs across retailers are returned,
+// each with its strongest method. Products without a brand are only matched
+// by EAN or model, since comparing every unbranded title is both slow and
+// unreliable.
+func findMatches(products []matchCandidate) []productMatch {
+	best := map[[2]int64]productMatch{}
+	add := func(a, b matchCandidate, method string, confidence float64) {
+		if a.ID == b.ID || a.Retailer == b.Retailer {
+			return
+		}
+		if a.ID > b.ID {
+			a, b = b, a
+		}
+		key := [2]int64{a.ID, b.ID}
+		if cur, ok := best[key]; ok && cur.Confidence >= confidence {
+			return
+		}
+		best[key] = productMatch{A: a.ID, B: b.ID, Method: method, Confidence: confidence}
+	}
+	pairs := func(groups map[string][]int, fn func(a, b matchCandidate)) {
+		for _, idx := range groups {
+			for i := 0; i < len(idx); i++ {
+				for j := i + 1; j < len(idx); j++ {
+					fn(products[idx[i]], products[idx[j]])
+				}
+			}
+		}
+	}
+
+	byEAN := map[string][]int{}
+	byModel := map[string][]int{}
+	byFamily := map[string][]int{}
+	byBrand := map[string][]int{}
+	for i := range products {
+		p := &products[i]
+		p.tokens = titleTokens(p.Title)
+		if p.EAN != "" {
+			byEAN[p.EAN] = append(byEAN[p.EAN], i)
+		}
+		if p.Model != "" {
+			byModel[p.Brand+"|"+p.Model] = append(byModel[p.Brand+"|"+p.Model], i)
+			if p.Brand != "" {
+				f := p.Brand + "|" + modelFamily(p.Model)
+				byFamily[f] = append(byFamily[f], i)
+			}
+		}
+		if p.Brand != "" {
+			byBrand[p.Brand] = append(byBrand[p.Brand], i)
+		}
+	}
+
+	pairs(byEAN, func(a, b matchCandidate) { add(a, b, matchByEAN, confidenceEAN) })
+	pairs(byModel, func(a, b matchCandidate) { add(a, b, matchByModel, confidenceModel) })
+	pairs(byFamily, func(a, b matchCandidate) { add(a, b, matchByModelFamily, confidenceModelFamily) })
+	pairs(byBrand, func(a, b matchCandidate) {
+		// different EANs are different products, however similar the titles
+		if a.EAN != "" && b.EAN != "" && a.EAN != b.EAN {
+			return
+		}
+		if s := titleSimilarity(a.tokens, b.tokens); s >= minTitleSimilarity {
+			add(a, b, matchByTitle, s*titleConfidenceWeight)
+		}
+	})
+
+	matches := make([]productMatch, 0, len(best))
+	for _, m := range best {
+		matches = append(matches, m)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].A != matches[j].A {
+			return matches[i].A < matches[j].A
+		}
+		return matches[i].B < matches[j].B
+	})
+	return matches
+}
+
+// MatchProducts recomputes cross-retailer matches for all products and
+// stores them in product_matches. Reviewed pairs keep their status; a pair
+// only gains confidence, never loses it.
+func MatchProducts(db *sql.DB) (int, error) {
+	rows, err := db.Query(`
+        SELECT id, retailer, COALESCE(ean, ''), COALESCE(brand, ''), COALESCE(model, ''), COALESCE(title, '')
+        FROM products
+    `)
+	if err != nil {
+		return 0, err
+	}
+	var products []matchCandidate
+	for rows.Next() {
+		var c matchCandidate
+		if err := rows.Scan(&c.ID, &c.Retailer, &c.EAN, &c.Brand, &c.Model, &c.Title); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		// rows crawled before brand/model were stored are parsed here
+		brand, model := parseBrandModel(c.Title)
+		if c.Brand == "" {
+			c.Brand = brand
+		}
+		if c.Model == "" {
+			c.Model = model
+		}
+		c.Brand = normaliseBrand(c.Brand)
+		c.Model = normaliseModel(c.Model)
+		products = append(products, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	matches := findMatches(products)
+	for _, m := range matches {
+		// method is assigned first: MySQL evaluates SET left to right
+		if _, err := db.Exec(`
+            INSERT INTO product_matches (product_a_id, product_b_id, method, confidence, status)
+            VALUES (?, ?, ?, ?, ?)
+            ON DUPLICATE KEY UPDATE
+                method = IF(VALUES(confidence) > confidence, VALUES(method), method),
+                confidence = GREATEST(confidence, VALUES(confidence))
+        `, m.A, m.B, m.Method, m.Confidence, string(matchPending)); err != nil {
+			return 0, err
+		}
+	}
+	return len(matches), nil
+}
+
+// SetMatchStatus records a review decision for a match.
+func SetMatchStatus(db *sql.DB, id int64, status matchStatus) error {
+	_, err := db.Exec(`UPDATE product_matches SET status = ?, reviewed_at = NOW() WHERE id = ?`, string(status), id)
+	return err
+}
+
+// matchSide is one product of a pending match as shown during review.
+type matchSide struct {
+	ID       int64
+	Retailer string
+	Title    string
+	URL      string
+	Price    sql.NullFloat64
+	Currency string
+}
+
+type pendingMatch struct {
+	ID         int64
+	Method     string
+	Confidence float64
+	A, B       matchSide
+}
+
+// ReviewMatches walks pending matches, most confident first, and asks on in
+// whether to confirm, reject or skip each one.
+func ReviewMatches(db *sql.DB, in io.Reader, out io.Writer) error {
+	rows, err := db.Query(`
+        SELECT m.id, m.method, m.confidence,
+               a.id, a.retailer, COALESCE(a.title, ''), a.source_url, a.price, COALESCE(a.currency, ''),
+               b.id, b.retailer, COALESCE(b.title, ''), b.source_url, b.price, COALESCE(b.currency, '')
+        FROM product_matches m
+        JOIN products a ON a.id = m.product_a_id
+        JOIN products b ON b.id = m.product_b_id
+        WHERE m.status = ?
+        ORDER BY m.confidence DESC, m.id
+    `, string(matchPending))
+	if err != nil {
+		return err
+	}
+	var pending []pendingMatch
+	for rows.Next() {
+		var m pendingMatch
+		if err := rows.Scan(&m.ID, &m.Method, &m.Confidence,
+			&m.A.ID, &m.A.Retailer, &m.A.Title, &m.A.URL, &m.A.Price, &m.A.Currency,
+			&m.B.ID, &m.B.Retailer, &m.B.Title, &m.B.URL, &m.B.Price, &m.B.Currency); err != nil {
+			rows.Close()
+			return err
+		}
+		pending = append(pending, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		fmt.Fprintln(out, "no pending matches")
+		return nil
+	}
+
+	scanner := bufio.NewScanner(in)
+	for i, m := range pending {
+		fmt.Fprintf(out, "\n[%d/%d] match #%d by %s (confidence %.2f)\n", i+1, len(pending), m.ID, m.Method, m.Confidence)
+		for _, s := range []matchSide{m.A, m.B} {
+			fmt.Fprintf(out, "  %-10s %10s  %s\n  %10s %10s  %s\n", s.Retailer, formatPrice(s.Price, s.Currency), s.Title, "", "", s.URL)
+		}
+		if m.A.Price.Valid && m.B.Price.Valid && m.A.Price.Float64 != m.B.Price.Float64 {
+			cheap, dear := m.A, m.B
+			if dear.Price.Float64 < cheap.Price.Float64 {
+				cheap, dear = dear, cheap
+			}
+			diff := dear.Price.Float64 - cheap.Price.Float64
+			fmt.Fprintf(out, "  %s is %.2f cheaper (%.1f%%)\n", cheap.Retailer, diff, 100*diff/dear.Price.Float64)
+		}
+		fmt.Fprint(out, "[c]onfirm, [r]eject, [s]kip, [q]uit: ")
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return scanner.Err()
+		}
+		switch strings.ToLower(strings.TrimSpace(scanner.Text())) {
+		case "c", "confirm":
+			if err := SetMatchStatus(db, m.ID, matchConfirmed); err != nil {
+				return err
+			}
+		case "r", "reject":
+			if err := SetMatchStatus(db, m.ID, matchRejected); err != nil {
+				return err
+			}
+		case "q", "quit":
+			return nil
+		}
+	}
+	return nil
+}
+
+func formatPrice(p sql.NullFloat64, currency string) string {
+	if !p.Valid {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f %s", p.Float64, currency)
+}
@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+func TestModelFamily(t *testing.T) {
+	tests := []struct {
+		model  string
+		family string
+	}{
+		{"DHP453", "DHP453"},
+		{"DHP453Z", "DHP453"},
+		{"DHP453RFX", "DHP453"},
+		{"DHP453RFX8", "DHP453"},
+		{"DCF620NXJ", "DCF620"},
+		{"DCD796D2", "DCD796"},
+		{"DCD796P2", "DCD796"},
+		{"HR2470", "HR2470"},
+		// different tools that share a voltage or platform prefix
+		{"GSR18V55", "GSR18V55"},
+		{"GSR18V60", "GSR18V60"},
+		{"GSR18V110", "GSR18V110"},
+		{"M18FPD2", "M18FPD2"},
+		{"M18FID2", "M18FID2"},
+		{"M18CHX", "M18CHX"},
+	}
+	for _, tt := range tests {
+		if got := modelFamily(tt.model); got != tt.family {
+			t.Errorf("modelFamily(%q) = %q, want %q", tt.model, got, tt.family)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+)
+
+// BackfillReferences sets products.reference from source_url for rows that
+// predate reference tracking.
+func BackfillReferences(db *sql.DB) (int, error) {
+	rows, err := db.Query(`SELECT id, source_url FROM products WHERE reference IS NULL`)
+	if err != nil {
+		return 0, err
+	}
+	type row struct {
+		id  int64
+		url string
+	}
+	var pending []row
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.id, &r.url); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		pending = append(pending, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, r := range pending {
+		ref := referenceFromURL(r.url)
+		if ref == "" {
+			continue
+		}
+		if _, err := db.Exec(`UPDATE products SET reference = ? WHERE id = ?`, ref, r.id); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// MergeDuplicateProducts folds every group of products sharing a retailer and
+// reference into its oldest row; retailers number their products
+// independently, so equal references of two retailers are different
+// products. History, images, documents, attributes, availability, matches and
+// quarantined prices move to the survivor, which takes the newest row's data
+// and the canonical URL.
+func MergeDuplicateProducts(db *sql.DB) (int, error) {
+	if n, err := BackfillReferences(db); err != nil {
+		return 0, fmt.Errorf("backfill references: %w", err)
+	} else if n > 0 {
+		slog.Info("backfilled references", "products", n)
+	}
+
+	rows, err := db.Query(`
+        SELECT retailer, reference FROM products WHERE reference IS NOT NULL
+        GROUP BY retailer, reference HAVING COUNT(*) > 1
+    `)
+	if err != nil {
+		return 0, err
+	}
+	type group struct{ retailer, ref string }
+	var groups []group
+	for rows.Next() {
+		var g group
+		if err := rows.Scan(&g.retailer, &g.ref); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		groups = append(groups, g)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	merged := 0
+	for _, g := range groups {
+		n, err := mergeReference(db, g.retailer, g.ref)
+		if err != nil {
+			return merged, fmt.Errorf("merge %s reference %s: %w", g.retailer, g.ref, err)
+		}
+		merged += n
+	}
+	return merged, nil
+}
+
+// mergeReference merges the rows of one retailer reference in a single
+// transaction and returns how many duplicates were removed.
+func mergeReference(db *sql.DB, retailer, ref string) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT id, source_url FROM products WHERE retailer = ? AND reference = ? ORDER BY id FOR UPDATE`, retailer, ref)
+	if err != nil {
+		return 0, err
+	}
+	var ids []int64
+	var urls []string
+	for rows.Next() {
+		var id int64
+		var u string
+		if err := rows.Scan(&id, &u); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+		urls = append(urls, u)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(ids) < 2 {
+		return 0, nil
+	}
+	survivor := ids[0]
+
+	// the most recently updated row has the freshest scrape
+	var newest int64
+	if err := tx.QueryRow(`
+        SELECT id FROM products WHERE retailer = ? AND reference = ? ORDER BY updated_at DESC, id DESC LIMIT 1
+    `, retailer, ref).Scan(&newest); err != nil {
+		return 0, err
+	}
+	if newest != survivor {
+		if _, err := tx.Exec(`
+            UPDATE products s JOIN products n ON n.id = ?
+            SET s.title = n.title, s.description = n.description, s.price = n.price,
+                s.price_text = n.price_text, s.currency = n.currency, s.status = n.status,
+                s.discontinued_at = n.discontinued_at, s.successor_url = n.successor_url,
+                s.last_seen_at = n.last_seen_at, s.ean = COALESCE(n.ean, s.ean),
+                s.brand = COALESCE(n.brand, s.brand), s.model = COALESCE(n.model, s.model),
+                s.category = COALESCE(n.category, s.category)
+            WHERE s.id = ?
+        `, newest, survivor); err != nil {
+			return 0, err
+		}
+	}
+
+	// the seller crawler's upload queue and listings reference products too;
+	// deleting a dup would drop its job and unlink its live listing. Their
+	// tables exist only once the seller crawler ran against this database.
+	var sellerStmts []string
+	for _, s := range []struct{ table, stmt string }{
+		{"listing_jobs", `UPDATE IGNORE listing_jobs SET product_id = ? WHERE product_id = ?`},
+		{"marketplace_listings", `UPDATE marketplace_listings SET product_id = ? WHERE product_id = ?`},
+	} {
+		ok, err := tableExists(tx, s.table)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			sellerStmts = append(sellerStmts, s.stmt)
+		}
+	}
+
+	for _, dup := range ids[1:] {
+		stmts := append([]string{
+			`UPDATE product_price_history SET product_id = ? WHERE product_id = ?`,
+			`UPDATE product_availability_history SET product_id = ? WHERE product_id = ?`,
+			`INSERT IGNORE INTO product_images (product_id, url, position) SELECT ?, url, position FROM product_images WHERE product_id = ?`,
+			`INSERT IGNORE INTO product_documents (product_id, url) SELECT ?, url FROM product_documents WHERE product_id = ?`,
+			`INSERT IGNORE INTO product_attributes (product_id, name, value, position) SELECT ?, name, value, position FROM product_attributes WHERE product_id = ?`,
+			`UPDATE price_quarantine SET product_id = ? WHERE product_id = ?`,
+			// a pair the survivor already has keeps its review; the dup's
+			// copy goes with the dup
+			`UPDATE IGNORE product_matches SET product_a_id = ? WHERE product_a_id = ?`,
+			`UPDATE IGNORE product_matches SET product_b_id = ? WHERE product_b_id = ?`,
+		}, sellerStmts...)
+		for _, stmt := range stmts {
+			if _, err := tx.Exec(stmt, survivor, dup); err != nil {
+				return 0, err
+			}
+		}
+		// availability rows are current state: keep the survivor's row for a
+		// store only if it is the newest product, otherwise take the dup's
+		if newest == dup {
+			if _, err := tx.Exec(`DELETE FROM product_availability WHERE product_id = ?`, survivor); err != nil {
+				return 0, err
+			}
+		}
+		if _, err := tx.Exec(`
+            UPDATE IGNORE product_availability SET product_id = ? WHERE product_id = ?
+        `, survivor, dup); err != nil {
+			return 0, err
+		}
+		if _, err := tx.Exec(`DELETE FROM products WHERE id = ?`, dup); err != nil {
+			return 0, err
+		}
+	}
+
+	canonical := canonicalURL(urls[0])
+	for _, u := range urls {
+		if referenceFromURL(u) == ref {
+			canonical = canonicalURL(u)
+			break
+		}
+	}
+	if _, err := tx.Exec(`UPDATE products SET source_url = ? WHERE id = ?`, canonical, survivor); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	slog.Info("merged duplicates", "retailer", retailer, "reference", ref, "duplicates", len(ids)-1, "product_id", survivor, "url", canonical)
+	return len(ids) - 1, nil
+}
+
+// tableExists reports whether the current database has the table.
+func tableExists(tx *sql.Tx, table string) (bool, error) {
+	var n int
+	err := tx.QueryRow(`
+        SELECT COUNT(*) FROM information_schema.TABLES
+        WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?
+    `, table).Scan(&n)
+	return n > 0, err
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// metrics is the process-wide registry exposed on -metrics-addr and written
+// to -metrics-file. It renders the Prometheus text exposition format itself
+// so the crawler does not need the client library.
+var metrics = newCrawlMetrics()
+
+// Results counted in obramat_crawler_pages_total.
+const (
+	pageOK      = "ok"
+	pageSkipped = "skipped"
+	pageGone    = "gone"
+	pageFailed  = "failed"
+)
+
+var (
+	stepBuckets    = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 45}
+	dbWriteBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1}
+)
+
+type crawlMetrics struct {
+	mu          sync.Mutex
+	pages       map[string]float64
+	failures    map[string]float64
+	steps       map[string]*histogram
+	dbWrites    map[string]*histogram
+	tabs        int
+	lastSuccess time.Time
+}
+
+func newCrawlMetrics() *crawlMetrics {
+	return &crawlMetrics{
+		pages:    map[string]float64{},
+		failures: map[string]float64{},
+		steps:    map[string]*histogram{},
+		dbWrites: map[string]*histogram{},
+	}
+}
+
+// histogram keeps cumulative bucket counts as Prometheus expects them.
+type histogram struct {
+	buckets []float64
+	counts  []float64
+	sum     float64
+	count   float64
+}
+
+func (h *histogram) observe(v float64) {
+	for i, b := range h.buckets {
+		if v <= b {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+func observe(m map[string]*histogram, key string, buckets []float64, v float64) {
+	h, ok := m[key]
+	if !ok {
+		h = &histogram{buckets: buckets, counts: make([]float64, len(buckets))}
+		m[key] = h
+	}
+	h.observe(v)
+}
+
+// recordPage counts the outcome of one URL; failures are also counted by
+// failureClass.
+func (m *crawlMetrics) recordPage(result string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pages[result]++
+	switch result {
+	case pageOK:
+		m.lastSuccess = time.Now()
+	case pageFailed:
+		m.failures[failureClass(err)]++
+	}
+}
+
+// observeStep records how long an extraction step took since start.
+func (m *crawlMetrics) observeStep(step string, start time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	observe(m.steps, step, stepBuckets, time.Since(start).Seconds())
+}
+
+// observeDBWrite records the latency of one DB write since start.
+func (m *crawlMetrics) observeDBWrite(op string, start time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	observe(m.dbWrites, op, dbWriteBuckets, time.Since(start).Seconds())
+}
+
+// tabOpened and tabClosed track the number of open browser tabs.
+func (m *crawlMetrics) tabOpened() {
+	m.mu.Lock()
+	m.tabs++
+	m.mu.Unlock()
+}
+
+func (m *crawlMetrics) tabClosed() {
+	m.mu.Lock()
+	m.tabs--
+	m.mu.Unlock()
+}
+
+// stepError tags an extraction failure with the step that failed, which
+// becomes its failure class.
+type stepError struct {
+	Step string
+	Err  error
+}
+
+func (e *stepError) Error() string { return e.Err.Error() }
+func (e *stepError) Unwrap() error { return e.Err }
+
+func stepFailed(step string, err error) error {
+	return &stepError{Step: step, Err: err}
+}
+
+// failureClass buckets an error for obramat_crawler_failures_total. Blocks and
+// navigation errors take precedence over the step, so bans show up as
+// "blocked"; a step comes before "timeout" because a redesign makes the price
+// or stock wait time out, and must show up as a rise in that step.
+func failureClass(err error) string {
+	var blocked *blockedError
+	var step *stepError
+	switch {
+	case err == nil:
+		return "none"
+	case errors.As(err, &blocked):
+		return "blocked"
+	case errors.Is(err, errNavigate):
+		return "navigate"
+	case errors.As(err, &step):
+		return step.Step
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	}
+	return "other"
+}
+
+// writeTo renders all metrics in the Prometheus text format.
+func (m *crawlMetrics) writeTo(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var b bytes.Buffer
+	writeCounter(&b, "obramat_crawler_pages_total", "Product URLs processed, by result.", "result", m.pages)
+	writeCounter(&b, "obramat_crawler_failures_total", "Failed product URLs, by failure class.", "class", m.failures)
+	writeHistogram(&b, "obramat_crawler_step_duration_seconds", "Duration of extraction steps.", "step", m.steps)
+	writeHistogram(&b, "obramat_crawler_db_write_duration_seconds", "Latency of DB writes, by operation.", "op", m.dbWrites)
+	fmt.Fprintf(&b, "# HELP obramat_crawler_browser_tabs Browser tabs currently open.\n# TYPE obramat_crawler_browser_tabs gauge\nobramat_crawler_browser_tabs %d\n", m.tabs)
+	if !m.lastSuccess.IsZero() {
+		fmt.Fprintf(&b, "# HELP obramat_crawler_last_success_timestamp_seconds Time of the last successfully saved product.\n# TYPE obramat_crawler_last_success_timestamp_seconds gauge\nobramat_crawler_last_success_timestamp_seconds %d\n", m.lastSuccess.Unix())
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+func writeCounter(b *bytes.Buffer, name, help, label string, values map[string]float64) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+	for _, k := range sortedKeys(values) {
+		fmt.Fprintf(b, "%s{%s=%q} %s\n", name, label, k, formatFloat(values[k]))
+	}
+}
+
+func writeHistogram(b *bytes.Buffer, name, help, label string, series map[string]*histogram) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+	for _, k := range sortedKeys(series) {
+		h := series[k]
+		for i, le := range h.buckets {
+			fmt.Fprintf(b, "%s_bucket{%s=%q,le=%q} %s\n", name, label, k, formatFloat(le), formatFloat(h.counts[i]))
+		}
+		fmt.Fprintf(b, "%s_bucket{%s=%q,le=\"+Inf\"} %s\n", name, label, k, formatFloat(h.count))
+		fmt.Fprintf(b, "%s_sum{%s=%q} %s\n", name, label, k, formatFloat(h.sum))
+		fmt.Fprintf(b, "%s_count{%s=%q} %s\n", name, label, k, formatFloat(h.count))
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// serveMetrics exposes /metrics on addr until ctx is cancelled.
+func serveMetrics(ctx context.Context, addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := metrics.writeTo(w); err != nil {
+			slog.Error("metrics write failed", "err", err)
+		}
+	})
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+	go func() {
+		slog.Info("metrics listening", "addr", addr, "path", "/metrics")
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("metrics server failed", "err", err)
+		}
+	}()
+}
+
+// writeMetricsFile writes the metrics for node_exporter's textfile collector.
+// The file is replaced atomically so the collector never reads a partial one.
+func writeMetricsFile(path string) error {
+	var b bytes.Buffer
+	if err := metrics.writeTo(&b); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b.Bytes(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// obramatRetailer is stored in products.retailer for this extractor.
+const obramatRetailer = "obramat"
+
+// Store whose availability is read for every product.
+const (
+	obramatStoreQuery = "08911, Badalona, Barcelona, España"
+	obramatStoreCity  = "Badalona"
+	obramatStoreName  = "Obramat Badalona"
+)
+
+// obramatStockURL matches the XHR that fills the store availability layer.
+var obramatStockURL = regexp.MustCompile(`(?i)/(stocks?|availabilit(y|ies)|disponibilidad)([/?]|$)`)
+
+const (
+	priceSel      = `.m-price.-main .m-price__line`
+	titleSel      = `h1.l-product-detail-presentation__title`
+	storeStockSel = `article[data-store-city="Badalona"] .stock-status_text`
+)
+
+// extractObramat loads an Obramat product page in tabCtx and reads price,
+// title, description, media and Badalona store stock.
+func extractObramat(tabCtx context.Context, url string) (productData, error) {
+	perURLCtx, cancel := context.WithTimeout(tabCtx, 45*time.Second)
+	defer cancel()
+	lg := loggerFrom(tabCtx)
+
+	var priceText string
+	var titleText string
+	var descriptionText string
+	var stockText string
+	var carouselImages []string
+	var techDocURL string
+
+	navStart := time.Now()
+	netTracker := trackNetwork(perURLCtx)
+	resp, err := chromedp.RunResponse(perURLCtx, chromedp.Navigate(url))
+	if err != nil {
+		return productData{}, fmt.Errorf("%w (%s): %w", errNavigate, url, err)
+	}
+	var status int64
+	if resp != nil {
+		status = resp.Status
+	}
+	if err := waitBounded(perURLCtx, 10*time.Second, func(ctx context.Context) error {
+		return netTracker.waitIdle(ctx, 500*time.Millisecond, 2)
+	}); err != nil {
+		lg.Warn("network idle wait timed out", "step", "navigate", "err", err)
+	}
+	metrics.observeStep("navigate", navStart)
+	if err := detectBlock(perURLCtx, url, status); err != nil {
+		return productData{}, err
+	}
+	pageURL := url
+	successor, err := checkLifecycle(perURLCtx, url, status)
+	if err != nil {
+		return productData{}, err
+	}
+	if successor != "" {
+		lg.Info("redirected to successor product", "step", "lifecycle", "successor_url", successor)
+		pageURL = successor
+	}
+	identity, err := readPageIdentity(perURLCtx)
+	if err != nil {
+		lg.Warn("identity extraction failed", "step", "identity", "err", err)
+	}
+	canonical, reference := resolveIdentity(pageURL, identity)
+	lg = lg.With("reference", reference)
+
+	if err := chromedp.Run(perURLCtx, chromedp.Evaluate(`
+		Array.from(new Set(
+			Array.from(document.querySelectorAll('.kl-swiper img')).map(img => {
+				let url = img.src || img.getAttribute('data-src') || '';
+				return url.split('?')[0];
+			}).filter(url => url.length > 0)
+		));
+	`, &carouselImages)); err != nil {
+		lg.Warn("carousel extraction failed", "step", "carousel", "err", err)
+	}
+
+	if err := chromedp.Run(perURLCtx, chromedp.Evaluate(`
+		(function() {
+			const link = document.querySelector('a[href*=".pdf"]');
+			if (link) {
+				return link.href.split('?')[0];
+			}
+			return '';
+		})();
+	`, &techDocURL)); err != nil {
+		lg.Warn("tech doc extraction failed", "step", "tech_doc", "err", err)
+	}
+
+	priceStart := time.Now()
+	err = chromedp.Run(perURLCtx,
+		chromedp.WaitVisible(priceSel, chromedp.ByQuery),
+		waitTextNonEmpty(priceSel, &priceText),
+	)
+	metrics.observeStep("price", priceStart)
+	if err != nil {
+		// Challenges injected after load only show up once the wait times out.
+		checkCtx, checkCancel := context.WithTimeout(tabCtx, 5*time.Second)
+		blockErr := detectBlock(checkCtx, url, status)
+		checkCancel()
+		if blockErr != nil {
+			return productData{}, blockErr
+		}
+		checkCtx, checkCancel = context.WithTimeout(tabCtx, 5*time.Second)
+		goneErr := checkUnavailable(checkCtx, url)
+		checkCancel()
+		if goneErr != nil {
+			return productData{}, goneErr
+		}
+		return productData{}, stepFailed("price", fmt.Errorf("price read failed (%s): %w", url, err))
+	}
+
+	if err := chromedp.Run(perURLCtx,
+		chromedp.Text(titleSel, &titleText, chromedp.ByQuery),
+	); err != nil {
+		return productData{}, stepFailed("title", fmt.Errorf("title read failed (%s): %w", url, err))
+	}
+
+	if err := chromedp.Run(perURLCtx,
+		chromedp.AttributeValue(`meta[name="description"]`, "content", &descriptionText, nil, chromedp.ByQuery),
+	); err != nil {
+		return productData{}, stepFailed("description", fmt.Errorf("description read failed (%s): %w", url, err))
+	}
+
+	stockStart := time.Now()
+	defer metrics.observeStep("stock", stockStart)
+	if err := chromedp.Run(perURLCtx,
+		chromedp.Click(`button.o-availabilities__actionButton.js-choose-store-in_store.js-cdl`, chromedp.ByQuery),
+	); err != nil {
+		return productData{}, stepFailed("stock", fmt.Errorf("availability click failed (%s): %w", url, err))
+	}
+
+	if err := chromedp.Run(perURLCtx,
+		chromedp.WaitVisible(`#contextLayerSearchInput--998`, chromedp.ByID),
+		waitDOMSettled(300*time.Millisecond, 3*time.Second),
+	); err != nil {
+		return productData{}, stepFailed("stock", fmt.Errorf("search input wait failed (%s): %w", url, err))
+	}
+
+	stockResp := awaitResponse(perURLCtx, obramatStockURL.MatchString)
+	if err := chromedp.Run(perURLCtx,
+		chromedp.SendKeys(`#contextLayerSearchInput--998`, obramatStoreQuery+"\n", chromedp.ByID),
+	); err != nil {
+		return productData{}, stepFailed("stock", fmt.Errorf("send keys failed (%s): %w", url, err))
+	}
+	stores, err := captureStoreStock(perURLCtx, stockResp)
+	if err != nil {
+		lg.Warn("stock API capture failed, falling back to DOM", "step", "stock", "err", err)
+	}
+	var avail availability
+	if s, ok := findStore(stores, obramatStoreCity); ok {
+		avail = s.availability()
+		lg.Debug("stock read from API", "step", "stock", "stores", len(stores))
+	} else {
+		if err == nil {
+			lg.Warn("store missing from stock API response, falling back to DOM", "step", "stock", "store", obramatStoreCity)
+		}
+		if err := chromedp.Run(perURLCtx,
+			chromedp.WaitVisible(storeStockSel, chromedp.ByQuery),
+			waitTextNonEmpty(storeStockSel, &stockText),
+		); err != nil {
+			return productData{}, stepFailed("stock", fmt.Errorf("stock read failed (%s): %w", url, err))
+		}
+		avail = parseAvailability(stockText)
+	}
+
+	priceText = strings.Split(priceText, "\n")[0]
+	titleText = strings.TrimSpace(titleText)
+	brand, model := parseBrandModel(titleText)
+	if identity.Brand != "" {
+		brand = identity.Brand
+	}
+
+	prod := productData{
+		Retailer:       obramatRetailer,
+		SourceURL:      canonical,
+		Reference:      reference,
+		EAN:            normaliseEAN(identity.GTIN),
+		Brand:          normaliseBrand(brand),
+		Model:          model,
+		Category:       breadcrumbCategory(identity.Breadcrumb, titleText),
+		Title:          titleText,
+		Description:    strings.TrimSpace(descriptionText),
+		PriceNumeric:   parsePrice(priceText),
+		PriceText:      strings.TrimSpace(priceText),
+		Currency:       "EUR",
+		CarouselImages: carouselImages,
+		Attributes:     identity.Attributes,
+		TechDocURL:     strings.TrimSpace(techDocURL),
+		Availability:   avail,
+		StoreCity:      obramatStoreCity,
+		StoreName:      obramatStoreName,
+		Stores:         stores,
+	}
+	if successor != "" && referenceFromURL(url) != reference {
+		prod.RedirectedFrom = url
+	}
+	return prod, nil
+}
+
+// captureStoreStock waits for the stock API response and parses every store
+// in it.
+func captureStoreStock(ctx context.Context, w *responseWaiter) ([]storeStock, error) {
+	var resp capturedResponse
+	if err := waitBounded(ctx, 10*time.Second, func(ctx context.Context) error {
+		var err error
+		resp, err = w.wait(ctx)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	if resp.Status >= 400 {
+		return nil, fmt.Errorf("stock API %s returned %d", resp.URL, resp.Status)
+	}
+	body, err := fetchResponseBody(ctx, resp.RequestID)
+	if err != nil {
+		return nil, fmt.Errorf("stock API body: %w", err)
+	}
+	return parseStoreStock(body)
+}
+
+// waitBounded runs wait with its own timeout so that an optional wait can
+// give up without consuming the caller's whole deadline.
+func waitBounded(ctx context.Context, timeout time.Duration, wait func(context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return wait(ctx)
+}
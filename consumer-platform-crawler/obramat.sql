@@ -23,6 +23,207 @@ CREATE DATABASE /*!32312 IF NOT EXISTS*/ `obramat` /*!40100 DEFAULT CHARACTER SE
 
 USE `obramat`;
 
+--
+-- Table structure for table `crawl_failures`
+--
+
+DROP TABLE IF EXISTS `crawl_failures`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+CREATE TABLE `crawl_failures` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `run_id` varchar(64) NOT NULL,
+  `url` varchar(512) NOT NULL,
+  `reference` varchar(32) DEFAULT NULL,
+  `failure_class` varchar(32) NOT NULL,
+  `error` text,
+  `artifacts_dir` varchar(512) DEFAULT NULL,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_failures_run` (`run_id`),
+  KEY `idx_failures_url` (`url`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `crawl_failures`
+--
+
+LOCK TABLES `crawl_failures` WRITE;
+/*!40000 ALTER TABLE `crawl_failures` DISABLE KEYS */;
+/*!40000 ALTER TABLE `crawl_failures` ENABLE KEYS */;
+UNLOCK TABLES;
+
+--
+-- Table structure for table `crawl_locks`
+--
+
+DROP TABLE IF EXISTS `crawl_locks`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+CREATE TABLE `crawl_locks` (
+  `name` varchar(64) NOT NULL,
+  `owner` varchar(255) NOT NULL,
+  `acquired_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `expires_at` timestamp NOT NULL,
+  PRIMARY KEY (`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `crawl_locks`
+--
+
+LOCK TABLES `crawl_locks` WRITE;
+/*!40000 ALTER TABLE `crawl_locks` DISABLE KEYS */;
+/*!40000 ALTER TABLE `crawl_locks` ENABLE KEYS */;
+UNLOCK TABLES;
+
+--
+-- Table structure for table `listing_jobs`
+--
+
+DROP TABLE IF EXISTS `listing_jobs`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+CREATE TABLE `listing_jobs` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `product_id` bigint NOT NULL,
+  `status` varchar(16) NOT NULL DEFAULT 'pending',
+  `attempts` int NOT NULL DEFAULT '0',
+  `reason` text,
+  `listing_id` varchar(64) DEFAULT NULL,
+  `listing_url` varchar(512) DEFAULT NULL,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  `started_at` timestamp NULL DEFAULT NULL,
+  `finished_at` timestamp NULL DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uniq_listing_job_product` (`product_id`),
+  KEY `idx_listing_jobs_status` (`status`,`id`),
+  CONSTRAINT `listing_jobs_ibfk_1` FOREIGN KEY (`product_id`) REFERENCES `products` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `listing_jobs`
+--
+
+LOCK TABLES `listing_jobs` WRITE;
+/*!40000 ALTER TABLE `listing_jobs` DISABLE KEYS */;
+/*!40000 ALTER TABLE `listing_jobs` ENABLE KEYS */;
+UNLOCK TABLES;
+
+--
+-- Table structure for table `marketplace_listings`
+--
+
+DROP TABLE IF EXISTS `marketplace_listings`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+CREATE TABLE `marketplace_listings` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `marketplace` varchar(32) NOT NULL DEFAULT 'wallapop',
+  `listing_id` varchar(64) NOT NULL,
+  `product_id` bigint DEFAULT NULL,
+  `title` text,
+  `price` decimal(12,2) DEFAULT NULL,
+  `currency` varchar(8) DEFAULT 'EUR',
+  `views` int NOT NULL DEFAULT '0',
+  `favourites` int NOT NULL DEFAULT '0',
+  `status` varchar(16) NOT NULL,
+  `url` varchar(512) DEFAULT NULL,
+  `first_seen_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `last_synced_at` timestamp NULL DEFAULT NULL,
+  `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  `description` text,
+  `photos` text,
+  `source_price` decimal(12,2) DEFAULT NULL,
+  `source_stock` int DEFAULT NULL,
+  `submitted_at` timestamp NULL DEFAULT NULL,
+  `delisted_reason` varchar(32) DEFAULT NULL,
+  `description_key` char(64) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uniq_marketplace_listing` (`marketplace`,`listing_id`),
+  KEY `idx_marketplace_listings_product` (`product_id`),
+  KEY `idx_marketplace_listings_status` (`status`),
+  CONSTRAINT `marketplace_listings_ibfk_1` FOREIGN KEY (`product_id`) REFERENCES `products` (`id`) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `marketplace_listings`
+--
+
+LOCK TABLES `marketplace_listings` WRITE;
+/*!40000 ALTER TABLE `marketplace_listings` DISABLE KEYS */;
+/*!40000 ALTER TABLE `marketplace_listings` ENABLE KEYS */;
+UNLOCK TABLES;
+
+--
+-- Table structure for table `price_quarantine`
+--
+
+DROP TABLE IF EXISTS `price_quarantine`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+CREATE TABLE `price_quarantine` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `product_id` bigint NOT NULL,
+  `run_id` varchar(64) NOT NULL,
+  `url` varchar(512) NOT NULL,
+  `price` decimal(12,2) NOT NULL,
+  `price_text` varchar(64) DEFAULT NULL,
+  `previous_price` decimal(12,2) DEFAULT NULL,
+  `pct_change` decimal(8,2) DEFAULT NULL,
+  `z_score` decimal(8,2) DEFAULT NULL,
+  `reason` varchar(255) NOT NULL,
+  `status` varchar(16) NOT NULL DEFAULT 'pending',
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `resolved_at` timestamp NULL DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_quarantine_product_status` (`product_id`,`status`),
+  CONSTRAINT `price_quarantine_ibfk_1` FOREIGN KEY (`product_id`) REFERENCES `products` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `price_quarantine`
+--
+
+LOCK TABLES `price_quarantine` WRITE;
+/*!40000 ALTER TABLE `price_quarantine` DISABLE KEYS */;
+/*!40000 ALTER TABLE `price_quarantine` ENABLE KEYS */;
+UNLOCK TABLES;
+
+--
+-- Table structure for table `product_attributes`
+--
+
+DROP TABLE IF EXISTS `product_attributes`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+CREATE TABLE `product_attributes` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `product_id` bigint NOT NULL,
+  `name` varchar(255) NOT NULL,
+  `value` varchar(512) NOT NULL,
+  `position` int DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uniq_product_attribute` (`product_id`,`name`),
+  CONSTRAINT `product_attributes_ibfk_1` FOREIGN KEY (`product_id`) REFERENCES `products` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `product_attributes`
+--
+
+LOCK TABLES `product_attributes` WRITE;
+/*!40000 ALTER TABLE `product_attributes` DISABLE KEYS */;
+/*!40000 ALTER TABLE `product_attributes` ENABLE KEYS */;
+UNLOCK TABLES;
+
 --
 -- Table structure for table `product_availability`
 --
@@ -37,6 +238,10 @@ CREATE TABLE `product_availability` (
   `store_name` varchar(255) DEFAULT NULL,
   `availability_text` varchar(255) DEFAULT NULL,
   `stock` int DEFAULT NULL,
+  `status` varchar(32) DEFAULT NULL,
+  `quantity_is_lower_bound` tinyint(1) NOT NULL DEFAULT '0',
+  `click_and_collect` tinyint(1) NOT NULL DEFAULT '0',
+  `home_delivery` tinyint(1) NOT NULL DEFAULT '0',
   PRIMARY KEY (`id`),
   UNIQUE KEY `uniq_product_store` (`product_id`,`store_city`,`store_name`),
   CONSTRAINT `product_availability_ibfk_1` FOREIGN KEY (`product_id`) REFERENCES `products` (`id`) ON DELETE CASCADE
@@ -49,7 +254,7 @@ CREATE TABLE `product_availability` (
 
 LOCK TABLES `product_availability` WRITE;
 /*!40000 ALTER TABLE `product_availability` DISABLE KEYS */;
-INSERT INTO `product_availability` VALUES (3,2,'Badalona','Obramat Badalona','11',11),(4,1,'Badalona','Obramat Badalona','9',9),(5,5,'Badalona','Obramat Badalona','12',12),(6,6,'Badalona','Obramat Badalona','3',3),(7,7,'Badalona','Obramat Badalona','3',3),(8,8,'Badalona','Obramat Badalona','27',27),(9,9,'Badalona','Obramat Badalona','6',6),(10,10,'Badalona','Obramat Badalona','27',27),(11,11,'Badalona','Obramat Badalona','70',70),(12,12,'Badalona','Obramat Badalona','83',83),(13,13,'Badalona','Obramat Badalona','16',16),(14,14,'Badalona','Obramat Badalona','53',53),(15,15,'Badalona','Obramat Badalona','46',46),(16,16,'Badalona','Obramat Badalona','8',8),(17,17,'Badalona','Obramat Badalona','19',19),(18,18,'Badalona','Obramat Badalona','19',19),(19,19,'Badalona','Obramat Badalona','39',39),(20,20,'Badalona','Obramat Badalona','10',10),(21,21,'Badalona','Obramat Badalona','11',11),(22,22,'Badalona','Obramat Badalona','27',27),(23,23,'Badalona','Obramat Badalona','63',63),(24,24,'Badalona','Obramat Badalona','40',40);
+INSERT INTO `product_availability` VALUES (3,2,'Badalona','Obramat Badalona','11',11,NULL,0,0,0),(4,1,'Badalona','Obramat Badalona','9',9,NULL,0,0,0),(5,5,'Badalona','Obramat Badalona','12',12,NULL,0,0,0),(6,6,'Badalona','Obramat Badalona','3',3,NULL,0,0,0),(7,7,'Badalona','Obramat Badalona','3',3,NULL,0,0,0),(8,8,'Badalona','Obramat Badalona','27',27,NULL,0,0,0),(9,9,'Badalona','Obramat Badalona','6',6,NULL,0,0,0),(10,10,'Badalona','Obramat Badalona','27',27,NULL,0,0,0),(11,11,'Badalona','Obramat Badalona','70',70,NULL,0,0,0),(12,12,'Badalona','Obramat Badalona','83',83,NULL,0,0,0),(13,13,'Badalona','Obramat Badalona','16',16,NULL,0,0,0),(14,14,'Badalona','Obramat Badalona','53',53,NULL,0,0,0),(15,15,'Badalona','Obramat Badalona','46',46,NULL,0,0,0),(16,16,'Badalona','Obramat Badalona','8',8,NULL,0,0,0),(17,17,'Badalona','Obramat Badalona','19',19,NULL,0,0,0),(18,18,'Badalona','Obramat Badalona','19',19,NULL,0,0,0),(19,19,'Badalona','Obramat Badalona','39',39,NULL,0,0,0),(20,20,'Badalona','Obramat Badalona','10',10,NULL,0,0,0),(21,21,'Badalona','Obramat Badalona','11',11,NULL,0,0,0),(22,22,'Badalona','Obramat Badalona','27',27,NULL,0,0,0),(23,23,'Badalona','Obramat Badalona','63',63,NULL,0,0,0),(24,24,'Badalona','Obramat Badalona','40',40,NULL,0,0,0);
 /*!40000 ALTER TABLE `product_availability` ENABLE KEYS */;
 UNLOCK TABLES;
 
@@ -68,6 +273,10 @@ CREATE TABLE `product_availability_history` (
   `availability_text` varchar(255) DEFAULT NULL,
   `stock` int DEFAULT NULL,
   `recorded_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `status` varchar(32) DEFAULT NULL,
+  `quantity_is_lower_bound` tinyint(1) NOT NULL DEFAULT '0',
+  `click_and_collect` tinyint(1) NOT NULL DEFAULT '0',
+  `home_delivery` tinyint(1) NOT NULL DEFAULT '0',
   PRIMARY KEY (`id`),
   KEY `idx_avail_hist_product_time` (`product_id`,`recorded_at`),
   CONSTRAINT `product_availability_history_ibfk_1` FOREIGN KEY (`product_id`) REFERENCES `products` (`id`) ON DELETE CASCADE
@@ -80,7 +289,7 @@ CREATE TABLE `product_availability_history` (
 
 LOCK TABLES `product_availability_history` WRITE;
 /*!40000 ALTER TABLE `product_availability_history` DISABLE KEYS */;
-INSERT INTO `product_availability_history` VALUES (1,1,'Badalona','Obramat Badalona','9',9,'2025-12-24 20:56:42'),(2,2,'Badalona','Obramat Badalona','11',11,'2025-12-24 21:01:51'),(3,2,'Badalona','Obramat Badalona','11',11,'2025-12-24 21:03:12'),(4,1,'Badalona','Obramat Badalona','9',9,'2025-12-24 21:03:21'),(5,5,'Badalona','Obramat Badalona','12',12,'2025-12-24 21:03:29'),(6,6,'Badalona','Obramat Badalona','3',3,'2025-12-24 21:03:37'),(7,7,'Badalona','Obramat Badalona','3',3,'2025-12-24 21:03:46'),(8,8,'Badalona','Obramat Badalona','27',27,'2025-12-24 21:03:54'),(9,9,'Badalona','Obramat Badalona','6',6,'2025-12-24 21:04:02'),(10,10,'Badalona','Obramat Badalona','27',27,'2025-12-24 21:04:10'),(11,11,'Badalona','Obramat Badalona','70',70,'2025-12-24 21:04:19'),(12,12,'Badalona','Obramat Badalona','83',83,'2025-12-24 21:04:27'),(13,13,'Badalona','Obramat Badalona','16',16,'2025-12-24 21:04:35'),(14,14,'Badalona','Obramat Badalona','53',53,'2025-12-24 21:04:43'),(15,15,'Badalona','Obramat Badalona','46',46,'2025-12-24 21:09:34'),(16,16,'Badalona','Obramat Badalona','8',8,'2025-12-24 21:09:43'),(17,17,'Badalona','Obramat Badalona','19',19,'2025-12-24 21:09:50'),(18,18,'Badalona','Obramat Badalona','19',19,'2025-12-24 21:09:58'),(19,19,'Badalona','Obramat Badalona','39',39,'2025-12-24 21:10:06'),(20,20,'Badalona','Obramat Badalona','10',10,'2025-12-24 21:10:14'),(21,21,'Badalona','Obramat Badalona','11',11,'2025-12-24 21:10:22'),(22,22,'Badalona','Obramat Badalona','27',27,'2025-12-24 21:10:29'),(23,23,'Badalona','Obramat Badalona','63',63,'2025-12-24 21:10:37'),(24,24,'Badalona','Obramat Badalona','40',40,'2025-12-24 21:10:45');
+INSERT INTO `product_availability_history` VALUES (1,1,'Badalona','Obramat Badalona','9',9,'2025-12-24 20:56:42',NULL,0,0,0),(2,2,'Badalona','Obramat Badalona','11',11,'2025-12-24 21:01:51',NULL,0,0,0),(3,2,'Badalona','Obramat Badalona','11',11,'2025-12-24 21:03:12',NULL,0,0,0),(4,1,'Badalona','Obramat Badalona','9',9,'2025-12-24 21:03:21',NULL,0,0,0),(5,5,'Badalona','Obramat Badalona','12',12,'2025-12-24 21:03:29',NULL,0,0,0),(6,6,'Badalona','Obramat Badalona','3',3,'2025-12-24 21:03:37',NULL,0,0,0),(7,7,'Badalona','Obramat Badalona','3',3,'2025-12-24 21:03:46',NULL,0,0,0),(8,8,'Badalona','Obramat Badalona','27',27,'2025-12-24 21:03:54',NULL,0,0,0),(9,9,'Badalona','Obramat Badalona','6',6,'2025-12-24 21:04:02',NULL,0,0,0),(10,10,'Badalona','Obramat Badalona','27',27,'2025-12-24 21:04:10',NULL,0,0,0),(11,11,'Badalona','Obramat Badalona','70',70,'2025-12-24 21:04:19',NULL,0,0,0),(12,12,'Badalona','Obramat Badalona','83',83,'2025-12-24 21:04:27',NULL,0,0,0),(13,13,'Badalona','Obramat Badalona','16',16,'2025-12-24 21:04:35',NULL,0,0,0),(14,14,'Badalona','Obramat Badalona','53',53,'2025-12-24 21:04:43',NULL,0,0,0),(15,15,'Badalona','Obramat Badalona','46',46,'2025-12-24 21:09:34',NULL,0,0,0),(16,16,'Badalona','Obramat Badalona','8',8,'2025-12-24 21:09:43',NULL,0,0,0),(17,17,'Badalona','Obramat Badalona','19',19,'2025-12-24 21:09:50',NULL,0,0,0),(18,18,'Badalona','Obramat Badalona','19',19,'2025-12-24 21:09:58',NULL,0,0,0),(19,19,'Badalona','Obramat Badalona','39',39,'2025-12-24 21:10:06',NULL,0,0,0),(20,20,'Badalona','Obramat Badalona','10',10,'2025-12-24 21:10:14',NULL,0,0,0),(21,21,'Badalona','Obramat Badalona','11',11,'2025-12-24 21:10:22',NULL,0,0,0),(22,22,'Badalona','Obramat Badalona','27',27,'2025-12-24 21:10:29',NULL,0,0,0),(23,23,'Badalona','Obramat Badalona','63',63,'2025-12-24 21:10:37',NULL,0,0,0),(24,24,'Badalona','Obramat Badalona','40',40,'2025-12-24 21:10:45',NULL,0,0,0);
 /*!40000 ALTER TABLE `product_availability_history` ENABLE KEYS */;
 UNLOCK TABLES;
 
@@ -139,6 +348,41 @@ INSERT INTO `product_images` VALUES (1,1,'https://media.adeo.com/media/4515703/m
 /*!40000 ALTER TABLE `product_images` ENABLE KEYS */;
 UNLOCK TABLES;
 
+--
+-- Table structure for table `product_matches`
+--
+
+DROP TABLE IF EXISTS `product_matches`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+CREATE TABLE `product_matches` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `product_a_id` bigint NOT NULL,
+  `product_b_id` bigint NOT NULL,
+  `method` varchar(32) NOT NULL,
+  `confidence` decimal(4,3) NOT NULL,
+  `status` varchar(16) NOT NULL DEFAULT 'pending',
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  `reviewed_at` timestamp NULL DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uniq_match_pair` (`product_a_id`,`product_b_id`),
+  KEY `idx_matches_status` (`status`,`confidence`),
+  KEY `product_b_id` (`product_b_id`),
+  CONSTRAINT `product_matches_ibfk_1` FOREIGN KEY (`product_a_id`) REFERENCES `products` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `product_matches_ibfk_2` FOREIGN KEY (`product_b_id`) REFERENCES `products` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `product_matches`
+--
+
+LOCK TABLES `product_matches` WRITE;
+/*!40000 ALTER TABLE `product_matches` DISABLE KEYS */;
+/*!40000 ALTER TABLE `product_matches` ENABLE KEYS */;
+UNLOCK TABLES;
+
 --
 -- Table structure for table `product_price_history`
 --
@@ -169,6 +413,37 @@ INSERT INTO `product_price_history` VALUES (1,1,140.00,'140','EUR','2025-12-24 2
 /*!40000 ALTER TABLE `product_price_history` ENABLE KEYS */;
 UNLOCK TABLES;
 
+--
+-- Table structure for table `product_validation_issues`
+--
+
+DROP TABLE IF EXISTS `product_validation_issues`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+CREATE TABLE `product_validation_issues` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `run_id` varchar(64) NOT NULL,
+  `url` varchar(512) NOT NULL,
+  `reference` varchar(32) DEFAULT NULL,
+  `rule_name` varchar(64) NOT NULL,
+  `severity` varchar(16) NOT NULL,
+  `message` text,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_validation_run` (`run_id`,`severity`),
+  KEY `idx_validation_rule` (`rule_name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `product_validation_issues`
+--
+
+LOCK TABLES `product_validation_issues` WRITE;
+/*!40000 ALTER TABLE `product_validation_issues` DISABLE KEYS */;
+/*!40000 ALTER TABLE `product_validation_issues` ENABLE KEYS */;
+UNLOCK TABLES;
+
 --
 -- Table structure for table `products`
 --
@@ -186,8 +461,20 @@ CREATE TABLE `products` (
   `currency` varchar(8) DEFAULT 'EUR',
   `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
   `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  `status` varchar(32) NOT NULL DEFAULT 'active',
+  `discontinued_at` timestamp NULL DEFAULT NULL,
+  `successor_url` varchar(512) DEFAULT NULL,
+  `last_seen_at` timestamp NULL DEFAULT NULL,
+  `reference` varchar(32) DEFAULT NULL,
+  `retailer` varchar(32) NOT NULL DEFAULT 'obramat',
+  `ean` varchar(14) DEFAULT NULL,
+  `brand` varchar(64) DEFAULT NULL,
+  `model` varchar(64) DEFAULT NULL,
+  `category` varchar(255) DEFAULT NULL,
   PRIMARY KEY (`id`),
-  UNIQUE KEY `source_url` (`source_url`)
+  UNIQUE KEY `source_url` (`source_url`),
+  KEY `idx_products_reference` (`reference`),
+  KEY `idx_products_ean` (`ean`)
 ) ENGINE=InnoDB AUTO_INCREMENT=25 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
 /*!40101 SET character_set_client = @saved_cs_client */;
 
@@ -197,7 +484,7 @@ CREATE TABLE `products` (
 
 LOCK TABLES `products` WRITE;
 /*!40000 ALTER TABLE `products` DISABLE KEYS */;
-INSERT INTO `products` VALUES (1,'https://www.obramat.es/productos/sierra-calar-bateria-brushless-18v-bosch-gst-18v-95-b-25087500.html','SIERRA CALAR BATERÍA BRUSHLESS 18V BOSCH GST 18V-95 B','Sierra calar batería Brushless 18V Bosch GST 18V-95 B. 0-3.000c.p.m. No incluye cargador ni baterías. Pendular en 4 niveles (órbita) para priorizar la rapidez del corte o la precisión/limpieza del corte. Profundidad de corte máximo en madera 95mm. Longitud de carrera 26mm. Velocidad variable. Interfaz de aspiración para una conexión eficaz de aspiración del polvo. Luz LED. ● Tipo de inseción de la hoja: ”T”● Sistema de fijación de la hoja: rápida. Cambio de hoja por sistema SDS. ● Peso: 1,6Kg● Ventajas del producto: Potente motor Brushless sin escobillas garantiza mayor duración de la herramienta y excelente autonomía.● Uso recomendado: ideal para realizar cortes curvos y transversales en madera maciza, tablero de aglomerado y compuestos de madera, así como en materiales más gruesos o duros.● Accesorios incluidos: 1 hoja de sierra y 1 conexión para aspirador.',140.00,'140','EUR','2025-12-24 20:56:42','2025-12-24 20:56:42'),(2,'https://www.obramat.es/productos/atornillador-placa-de-yeso-a-bateria-brushless-18v-dewalt-dcf620n-xj-25055718.html','ATORNILLADOR PLACA DE YESO A BATERIA BRUSHLESS 18V DEWALT DCF620N-XJ','Atornillador placa de yeso a batería Brushless 18V Dewalt DCF620N-XJ. Inserción hexagonal 6,35mm. 4.400r.p.m. No incluye cargador ni baterías. Luz LED de trabajo. Gatillo con bloqueo. Torque Máximo 30/5 Nm. ● Peso: 1,48Kg. ● Ventajas de producto: .Diseño compacto y peso reducido para trabajos sin fatiga muscular en la muñeca. ● Uso recomendado: Atornillado intensivo en instalaciones de tabiquería de catón yeso.',164.00,'164','EUR','2025-12-24 21:01:51','2025-12-24 21:01:51'),(5,'https://www.obramat.es/productos/taladro-percutor-bateria-makita-dhp453rfx8-18v-3ah-25022742.html','TALADRO PERCUTOR BATERÍA MAKITA DHP453RFX8 18V 3AH','Taladro percutor batería Makita DHP453RFX8 18V 3Ah. Portabrocas plástico 13mm. 2 velocidades 0-400 / 0-1.300r.p.m. 42Nm de par de giro. 1 batería de 3Ah. ● Peso: 2Kg.● Ventajas del producto: Velocidad regulable que proporciona un control facíl y preciso de las r.p.m. Con engranajes metálicos para una mayor durabilidad de la herramienta. ● Uso recomendado: Taladrado y atornillado uso intensivo.● Accesorios incluidos: 1 batería, cargador.',139.00,'139','EUR','2025-12-24 21:03:29','2025-12-24 21:03:29'),(6,'https://www.obramat.es/productos/martillo-combinado-bateria-brushless-dewalt-18v-2-6j-25046591.html','MARTILLO COMBINADO BATERÍA BRUSHLESS DEWALT 18V 2.6J','Martillo combinado batería Brushless Dewalt DCH133N-XJ 18V 2.6J. SDS Plus. Velocidad variable 0-1.550r.p.m. / 0-5.680i.p.m. No incluye cargador ni baterías. 2 modos de trabajo. Máximos de perforación: homigón 26mm, metal 13mm, madera 30mm. ● Motor: Horizontal. ● Peso: 2.3Kg● Ventajas del producto: Motor sin escobillas ofrece una mayor durabilidad. ● Uso recomendado: Trabajos intensivo de instalaciones que requieren perforación media (pasamuros, canalizaciones medias, luminarias, instalaciones de fachadas...). Ideal para perforaciones de anclajes en hormigón y ladrillo desde 4mm hasta 26mm.● Accesorios incluidos: Empuñadura multi-posición.',171.00,'171','EUR','2025-12-24 21:03:37','2025-12-24 21:03:37'),(7,'https://www.obramat.es/productos/martillo-demoledor-makita-hm0870c-1100w-sds-max-10029616.html','MARTILLO DEMOLEDOR MAKITA HM0870C 1100W SDS-MAX','Martillo demoledor Makita HM0870C 1100W SDS-MAX. 2.650i.p.m. Peso 5.1Kg. Con regulador de velocidad y velocidad variable. Cuerpo de motor vertical.● Ventajas: Regulador de la posición del cincel e indicador de mantenimiento que avisa del cambio de escobilla o avería. ● Uso recomendado: Demolición intensiva tabiquería y pequeñas demoliciones de pavimentos.● Accesorios incluidos: Maletín, empuñadura y tubo de grasa.',346.00,'346','EUR','2025-12-24 21:03:46','2025-12-24 21:03:46'),(8,'https://www.obramat.es/productos/taladro-percutor-bosch-gsb600-600w-10790262.html','TALADRO PERCUTOR BOSCH GSB600 600W','Taladro percutor Bosch GSB 13 RE 600W. Portabrocas automático de 13mm. Velocidad variable con regulador 0 - 2.800r.p.m. Par de giro nominal 1,8Nm. 44.800i.p.m. Ø de perforación en hormigon 13mm, mampostería 15mm y madera 25mm. Con regulador de velocidad y portabrocas metálico.● Peso: 1,8Kg.● Ventajas del producto: Control fácil y preciso de la velocidad, ajuste de las r.p.m. según trabajo a realizar y mayor durabilidad y agarre de la broca.● Uso recomendado: Taladrado intensivo en madera, metal y mampostería● Accesorios incluidos: Tope profundidad y empuñadura.',76.00,'76','EUR','2025-12-24 21:03:54','2025-12-24 21:03:54'),(9,'https://www.obramat.es/productos/bateria-makita-bl1830-3ah-12102120.html','BATERIA MAKITA BL1830 3AH','Batería Makita BL1830 3Ah. Con indicador de nivel de carga. Tiempo de carga aprox. 24 min. con un cargador rápido. Batería LI-ion. ● Peso: 0.64Kg. ● Ventajas producto: Carga rápida.',50.00,'50','EUR','2025-12-24 21:04:02','2025-12-24 21:04:02'),(10,'https://www.obramat.es/productos/conjunto-kit-cierre-puerta-corredera-20mm-canto-redondo-muletilla-pulido-25033988.html','CONJUNTO KIT CIERRE PUERTA CORREDERA 20MM CANTO REDONDO MULETILLA PULIDO','Conjunto para puertas correderas compuesto por :- Cerradura con cerradero fabricado en zamak con entrada de 50 mm, nueca de 8 mm y canto redondo ½.-Condena y desbloqueo fabricado en zamak-Uñero fabricado en zamak a colocar en el canto de la puerta y utilizado como tirador.Acabado pulido. La condena y desbloqueo accionan el gancho de la cerradura con una vuelta. La fijación se realiza mediante tirafondos en el caso de la cerradura. La condena y desbloqueo se fijan a presión, mediante pegamento y mediante tornillo. El uñero se fija mediante presión y pegamento',19.00,'19','EUR','2025-12-24 21:04:10','2025-12-24 21:04:10'),(11,'https://www.obramat.es/productos/condena-descondena-cuadrada-aluminio-50-mm-negro-niquel-25033997.html','CONDENA/DESCONDENA CUADRADA ALUMINIO 50 MM NEGRO/NIQUEL','Condena/\n  des condena de roseta cuadrada realizada en aluminio y acabado en negro/\n  níquel de 50 mm. Ideal para las puertas de baño',12.00,'12','EUR','2025-12-24 21:04:19','2025-12-24 21:04:19'),(12,'https://www.obramat.es/productos/cerradura-vaiven-mueble-empotrar-120mm-laton-10517731.html','CERRADURA VAIVEN MUEBLE EMPOTRAR 120MM LATÓN','Cerradura vaiven mueble empotrar 120mm latón. Ideal para asegurar la privacidad y funcionalidad de tus muebles.\n\nTipo: mueble.\nMaterial: latón.\nAcabado: latón.\nMedidas/Dimensiones: eje de 120mm.\nModelo: cerradura para muebles.\nVentajas del Producto: este tipo de cerradura es reversible y se adapta a diferentes necesidades, facilitando su instalación en distintos tipos de muebles.',5.00,'5','EUR','2025-12-24 21:04:27','2025-12-24 21:04:27'),(13,'https://www.obramat.es/productos/juego-6-destornilladores-mixtos-kenston-1570534.html','JUEGO 6 DESTORNILLADORES MIXTOS KENSTON','Juego 6 destornilladores mixtos: Philips PH1x100mm, PH2x125mm, PH3x150mm. Hoja de cromo vanadio.',12.00,'12','EUR','2025-12-24 21:04:35','2025-12-24 21:04:35'),(14,'https://www.obramat.es/productos/juego-6-destornilladores-de-precision-kenston-25037181.html','JUEGO 6 DESTORNILLADORES DE PRECISIÓN KENSTON','Juego de destornilladores de precisión de 6 unidades; Acabado satinado de la hoja Cr-V; Dos destornilladores planos, dos Phillips y dos de estrella.',7.00,'7','EUR','2025-12-24 21:04:43','2025-12-24 21:04:43'),(15,'https://www.obramat.es/productos/cronotermostato-wifi-para-empotrar-10793895.html','CRONOTERMOSTATO WIFI PARA EMPOTRAR','Cronotermostato WIFI digital que permite comunicación Wifi con App para gestión remota. Compatible con Alexa. Pantalla LCD con retroiluminación que permite la visualización de la temperatura ambiente y la temperatura de ajuste. Programación configurable en 6 intervalos diarios. Rango de ajuste de temperatura de 5ºC a 35ºC.',49.00,'49','EUR','2025-12-24 21:09:34','2025-12-24 21:09:34'),(16,'https://www.obramat.es/productos/termostato-digital-orkli-10477712.html','TERMOSTATO DIGITAL ORKLI','Termostato digital modelo ON/OFF que permite la regulación de la temperatura de calefacción mediante control manual de la misma. Muestra además, la temperatura ambiente. Intervalo de ajuste de temperatura de 10ºC a 30ºC. Temperatura de funcionamiento de 0ºC a 45ºC. Intervalo de humedad 5-95% de humedad relativa sin condensado. Grado de protección IP20.',31.00,'31','EUR','2025-12-24 21:09:43','2025-12-24 21:09:43'),(17,'https://www.obramat.es/productos/cronotermostato-semanal-orkli-10477740.html','CRONOTERMOSTATO SEMANAL ORKLI','Cronotermostato digital que permite la regulacion de temperatura tanto en calefaccion como refrigeracion diaria en intervalos de media hora, posibilidad de configurar dos tipos de temperatura en cada modo de funcionamiento (calefaccion o refrigeracion). Regulación de temperatura de 5ºC a 30ºC.',55.00,'55','EUR','2025-12-24 21:09:50','2025-12-24 21:09:50'),(18,'https://www.obramat.es/productos/monomando-fregadero-gerontologico-10572296.html','MONOMANDO FREGADERO GERONTOLOGICO','Los monomandos de cocina, tienen muchas ventajas: permiten una mayor precisión en la regulación del caudal de agua, ya que con un solo gesto vertical es posible ajustar la cantidad de acuerdo con las necesidades de cada aplicación, lo que permite al mismo tiempo ahorrar una gran cantidad de agua. Además de ser más cómodo y útil, evita las quemaduras.\nSus dos discos cerámicos que alberga en su interior y que incrementan la durabilidad del grifo, además de necesitar un menor mantenimiento que el resto de tipologías de grifos.',35.00,'35','EUR','2025-12-24 21:09:58','2025-12-24 21:09:58'),(19,'https://www.obramat.es/productos/monomando-fregadero-encimera-kirkland-10787770.html','MONOMANDO FREGADERO ENCIMERA KIRKLAND','Los monomandos de cocina, tienen muchas ventajas: permiten una mayor precisión en la regulación del caudal de agua, ya que con un solo gesto vertical es posible ajustar la cantidad de acuerdo con las necesidades de cada aplicación, lo que permite al mismo tiempo ahorrar una gran cantidad de agua. Además de ser más cómodo y útil, evita las quemaduras.\nSus dos discos cerámicos que alberga en su interior y que incrementan la durabilidad del grifo, además de necesitar un menor mantenimiento que el resto de tipologías de grifos.',38.00,'38','EUR','2025-12-24 21:10:06','2025-12-24 21:10:06'),(20,'https://www.obramat.es/productos/grifo-de-cocina-monomando-pvd-atlantis-inox-25094718.html','GRIFO DE COCINA MONOMANDO PVD ATLANTIS INOX','Grifo de cocina monomando, acabado inox, de caño alto. Medidas 350x205mm Ø50mm.Material: acero.Instalación: sobre encimera.Mecanismo: con cartucho de disco cerámico de Ø35mm.Tipo de apertura: simple.Aireador: simple.Ventajas del producto: grifo de cocina de caño alto, con un diseño moderno y sencillo.',69.00,'69','EUR','2025-12-24 21:10:14','2025-12-24 21:10:14'),(21,'https://www.obramat.es/productos/grifo-de-cocina-extraible-pvd-oro-cepillado-25098788.html','GRIFO DE COCINA EXTRAIBLE PVD ORO CEPILLADO','Grifo de cocina monomando, de la marca Corberó. modelo PVD oro cepillado. Fabricado en acero inoxidable. Acabado PVD. De caño alto. Material: Cuerpo y maneta en acero inoxidable.Instalación: sobre encimera o en fregadero.Cabezal extraible, de 2 funciones.Mecanismo: cartucho cerámico de Ø40 mm.Tipo de apertura: simple.',119.00,'119','EUR','2025-12-24 21:10:22','2025-12-24 21:10:22'),(22,'https://www.obramat.es/productos/monomando-fregadero-atis-l-roca-10834446.html','MONOMANDO FREGADERO ATIS L ROCA','Los monomandos de cocina, tienen muchas ventajas: permiten una mayor precisión en la regulación del caudal de agua, ya que con un solo gesto vertical es posible ajustar la cantidad de acuerdo con las necesidades de cada aplicación, lo que permite al mismo tiempo ahorrar una gran cantidad de agua. Además de ser más cómodo y útil, evita las quemaduras.\nSus dos discos cerámicos que alberga en su interior y que incrementan la durabilidad del grifo, además de necesitar un menor mantenimiento que el resto de tipologías de grifos.',87.00,'87','EUR','2025-12-24 21:10:29','2025-12-24 21:10:29'),(23,'https://www.obramat.es/productos/grifo-cocina-monomando-cano-bajo-talos-pro-25067475.html','GRIFO COCINA MONOMANDO CAÑO BAJO TALOS PRO','Grifo de cocina monomando de la marca Ramon Soler, modelo Talos pro, acabado cromo, de caño bajo. Medidas 230x145mm.Material: latón.Instalación: sobre encimera.Mecanismo: con cartucho de disco cerámico de Ø40mm.Tipo de apertura: dos posiciones.Aireador: standard.Ventajas del producto: grifo de cocina de caño bajo, con apertura de dos posiciones. De estilo sencillo.',36.00,'36','EUR','2025-12-24 21:10:37','2025-12-24 21:10:37'),(24,'https://www.obramat.es/productos/grifo-de-cocina-monomando-vulcano-10467261.html','GRIFO DE COCINA MONOMANDO VULCANO','Los monomandos de cocina, tienen muchas ventajas: permiten una mayor precisión en la regulación del caudal de agua, ya que con un solo gesto vertical es posible ajustar la cantidad de acuerdo con las necesidades de cada aplicación, lo que permite al mismo tiempo ahorrar una gran cantidad de agua. Además de ser más cómodo y útil, evita las quemaduras.\nSus dos discos cerámicos que alberga en su interior y que incrementan la durabilidad del grifo, además de necesitar un menor mantenimiento que el resto de tipologías de grifos.',48.00,'48','EUR','2025-12-24 21:10:45','2025-12-24 21:10:45');
+INSERT INTO `products` VALUES (1,'https://www.obramat.es/productos/sierra-calar-bateria-brushless-18v-bosch-gst-18v-95-b-25087500.html','SIERRA CALAR BATERÍA BRUSHLESS 18V BOSCH GST 18V-95 B','Sierra calar batería Brushless 18V Bosch GST 18V-95 B. 0-3.000c.p.m. No incluye cargador ni baterías. Pendular en 4 niveles (órbita) para priorizar la rapidez del corte o la precisión/limpieza del corte. Profundidad de corte máximo en madera 95mm. Longitud de carrera 26mm. Velocidad variable. Interfaz de aspiración para una conexión eficaz de aspiración del polvo. Luz LED. ● Tipo de inseción de la hoja: ”T”● Sistema de fijación de la hoja: rápida. Cambio de hoja por sistema SDS. ● Peso: 1,6Kg● Ventajas del producto: Potente motor Brushless sin escobillas garantiza mayor duración de la herramienta y excelente autonomía.● Uso recomendado: ideal para realizar cortes curvos y transversales en madera maciza, tablero de aglomerado y compuestos de madera, así como en materiales más gruesos o duros.● Accesorios incluidos: 1 hoja de sierra y 1 conexión para aspirador.',140.00,'140','EUR','2025-12-24 20:56:42','2025-12-24 20:56:42','active',NULL,NULL,NULL,NULL,'obramat',NULL,NULL,NULL,NULL),(2,'https://www.obramat.es/productos/atornillador-placa-de-yeso-a-bateria-brushless-18v-dewalt-dcf620n-xj-25055718.html','ATORNILLADOR PLACA DE YESO A BATERIA BRUSHLESS 18V DEWALT DCF620N-XJ','Atornillador placa de yeso a batería Brushless 18V Dewalt DCF620N-XJ. Inserción hexagonal 6,35mm. 4.400r.p.m. No incluye cargador ni baterías. Luz LED de trabajo. Gatillo con bloqueo. Torque Máximo 30/5 Nm. ● Peso: 1,48Kg. ● Ventajas de producto: .Diseño compacto y peso reducido para trabajos sin fatiga muscular en la muñeca. ● Uso recomendado: Atornillado intensivo en instalaciones de tabiquería de catón yeso.',164.00,'164','EUR','2025-12-24 21:01:51','2025-12-24 21:01:51','active',NULL,NULL,NULL,NULL,'obramat',NULL,NULL,NULL,NULL),(5,'https://www.obramat.es/productos/taladro-percutor-bateria-makita-dhp453rfx8-18v-3ah-25022742.html','TALADRO PERCUTOR BATERÍA MAKITA DHP453RFX8 18V 3AH','Taladro percutor batería Makita DHP453RFX8 18V 3Ah. Portabrocas plástico 13mm. 2 velocidades 0-400 / 0-1.300r.p.m. 42Nm de par de giro. 1 batería de 3Ah. ● Peso: 2Kg.● Ventajas del producto: Velocidad regulable que proporciona un control facíl y preciso de las r.p.m. Con engranajes metálicos para una mayor durabilidad de la herramienta. ● Uso recomendado: Taladrado y atornillado uso intensivo.● Accesorios incluidos: 1 batería, cargador.',139.00,'139','EUR','2025-12-24 21:03:29','2025-12-24 21:03:29','active',NULL,NULL,NULL,NULL,'obramat',NULL,NULL,NULL,NULL),(6,'https://www.obramat.es/productos/martillo-combinado-bateria-brushless-dewalt-18v-2-6j-25046591.html','MARTILLO COMBINADO BATERÍA BRUSHLESS DEWALT 18V 2.6J','Martillo combinado batería Brushless Dewalt DCH133N-XJ 18V 2.6J. SDS Plus. Velocidad variable 0-1.550r.p.m. / 0-5.680i.p.m. No incluye cargador ni baterías. 2 modos de trabajo. Máximos de perforación: homigón 26mm, metal 13mm, madera 30mm. ● Motor: Horizontal. ● Peso: 2.3Kg● Ventajas del producto: Motor sin escobillas ofrece una mayor durabilidad. ● Uso recomendado: Trabajos intensivo de instalaciones que requieren perforación media (pasamuros, canalizaciones medias, luminarias, instalaciones de fachadas...). Ideal para perforaciones de anclajes en hormigón y ladrillo desde 4mm hasta 26mm.● Accesorios incluidos: Empuñadura multi-posición.',171.00,'171','EUR','2025-12-24 21:03:37','2025-12-24 21:03:37','active',NULL,NULL,NULL,NULL,'obramat',NULL,NULL,NULL,NULL),(7,'https://www.obramat.es/productos/martillo-demoledor-makita-hm0870c-1100w-sds-max-10029616.html','MARTILLO DEMOLEDOR MAKITA HM0870C 1100W SDS-MAX','Martillo demoledor Makita HM0870C 1100W SDS-MAX. 2.650i.p.m. Peso 5.1Kg. Con regulador de velocidad y velocidad variable. Cuerpo de motor vertical.● Ventajas: Regulador de la posición del cincel e indicador de mantenimiento que avisa del cambio de escobilla o avería. ● Uso recomendado: Demolición intensiva tabiquería y pequeñas demoliciones de pavimentos.● Accesorios incluidos: Maletín, empuñadura y tubo de grasa.',346.00,'346','EUR','2025-12-24 21:03:46','2025-12-24 21:03:46','active',NULL,NULL,NULL,NULL,'obramat',NULL,NULL,NULL,NULL),(8,'https://www.obramat.es/productos/taladro-percutor-bosch-gsb600-600w-10790262.html','TALADRO PERCUTOR BOSCH GSB600 600W','Taladro percutor Bosch GSB 13 RE 600W. Portabrocas automático de 13mm. Velocidad variable con regulador 0 - 2.800r.p.m. Par de giro nominal 1,8Nm. 44.800i.p.m. Ø de perforación en hormigon 13mm, mampostería 15mm y madera 25mm. Con regulador de velocidad y portabrocas metálico.● Peso: 1,8Kg.● Ventajas del producto: Control fácil y preciso de la velocidad, ajuste de las r.p.m. según trabajo a realizar y mayor durabilidad y agarre de la broca.● Uso recomendado: Taladrado intensivo en madera, metal y mampostería● Accesorios incluidos: Tope profundidad y empuñadura.',76.00,'76','EUR','2025-12-24 21:03:54','2025-12-24 21:03:54','active',NULL,NULL,NULL,NULL,'obramat',NULL,NULL,NULL,NULL),(9,'https://www.obramat.es/productos/bateria-makita-bl1830-3ah-12102120.html','BATERIA MAKITA BL1830 3AH','Batería Makita BL1830 3Ah. Con indicador de nivel de carga. Tiempo de carga aprox. 24 min. con un cargador rápido. Batería LI-ion. ● Peso: 0.64Kg. ● Ventajas producto: Carga rápida.',50.00,'50','EUR','2025-12-24 21:04:02','2025-12-24 21:04:02','active',NULL,NULL,NULL,NULL,'obramat',NULL,NULL,NULL,NULL),(10,'https://www.obramat.es/productos/conjunto-kit-cierre-puerta-corredera-20mm-canto-redondo-muletilla-pulido-25033988.html','CONJUNTO KIT CIERRE PUERTA CORREDERA 20MM CANTO REDONDO MULETILLA PULIDO','Conjunto para puertas correderas compuesto por :- Cerradura con cerradero fabricado en zamak con entrada de 50 mm, nueca de 8 mm y canto redondo ½.-Condena y desbloqueo fabricado en zamak-Uñero fabricado en zamak a colocar en el canto de la puerta y utilizado como tirador.Acabado pulido. La condena y desbloqueo accionan el gancho de la cerradura con una vuelta. La fijación se realiza mediante tirafondos en el caso de la cerradura. La condena y desbloqueo se fijan a presión, mediante pegamento y mediante tornillo. El uñero se fija mediante presión y pegamento',19.00,'19','EUR','2025-12-24 21:04:10','2025-12-24 21:04:10','active',NULL,NULL,NULL,NULL,'obramat',NULL,NULL,NULL,NULL),(11,'https://www.obramat.es/productos/condena-descondena-cuadrada-aluminio-50-mm-negro-niquel-25033997.html','CONDENA/DESCONDENA CUADRADA ALUMINIO 50 MM NEGRO/NIQUEL','Condena/\n  des condena de roseta cuadrada realizada en aluminio y acabado en negro/\n  níquel de 50 mm. Ideal para las puertas de baño',12.00,'12','EUR','2025-12-24 21:04:19','2025-12-24 21:04:19','active',NULL,NULL,NULL,NULL,'obramat',NULL,NULL,NULL,NULL),(12,'https://www.obramat.es/productos/cerradura-vaiven-mueble-empotrar-120mm-laton-10517731.html','CERRADURA VAIVEN MUEBLE EMPOTRAR 120MM LATÓN','Cerradura vaiven mueble empotrar 120mm latón. Ideal para asegurar la privacidad y funcionalidad de tus muebles.\n\nTipo: mueble.\nMaterial: latón.\nAcabado: latón.\nMedidas/Dimensiones: eje de 120mm.\nModelo: cerradura para muebles.\nVentajas del Producto: este tipo de cerradura es reversible y se adapta a diferentes necesidades, facilitando su instalación en distintos tipos de muebles.',5.00,'5','EUR','2025-12-24 21:04:27','2025-12-24 21:04:27','active',NULL,NULL,NULL,NULL,'obramat',NULL,NULL,NULL,NULL),(13,'https://www.obramat.es/productos/juego-6-destornilladores-mixtos-kenston-1570534.html','JUEGO 6 DESTORNILLADORES MIXTOS KENSTON','Juego 6 destornilladores mixtos: Philips PH1x100mm, PH2x125mm, PH3x150mm. Hoja de cromo vanadio.',12.00,'12','EUR','2025-12-24 21:04:35','2025-12-24 21:04:35','active',NULL,NULL,NULL,NULL,'obramat',NULL,NULL,NULL,NULL),(14,'https://www.obramat.es/productos/juego-6-destornilladores-de-precision-kenston-25037181.html','JUEGO 6 DESTORNILLADORES DE PRECISIÓN KENSTON','Juego de destornilladores de precisión de 6 unidades; Acabado satinado de la hoja Cr-V; Dos destornilladores planos, dos Phillips y dos de estrella.',7.00,'7','EUR','2025-12-24 21:04:43','2025-12-24 21:04:43','active',NULL,NULL,NULL,NULL,'obramat',NULL,NULL,NULL,NULL),(15,'https://www.obramat.es/productos/cronotermostato-wifi-para-empotrar-10793895.html','CRONOTERMOSTATO WIFI PARA EMPOTRAR','Cronotermostato WIFI digital que permite comunicación Wifi con App para gestión remota. Compatible con Alexa. Pantalla LCD con retroiluminación que permite la visualización de la temperatura ambiente y la temperatura de ajuste. Programación configurable en 6 intervalos diarios. Rango de ajuste de temperatura de 5ºC a 35ºC.',49.00,'49','EUR','2025-12-24 21:09:34','2025-12-24 21:09:34','active',NULL,NULL,NULL,NULL,'obramat',NULL,NULL,NULL,NULL),(16,'https://www.obramat.es/productos/termostato-digital-orkli-10477712.html','TERMOSTATO DIGITAL ORKLI','Termostato digital modelo ON/OFF que permite la regulación de la temperatura de calefacción mediante control manual de la misma. Muestra además, la temperatura ambiente. Intervalo de ajuste de temperatura de 10ºC a 30ºC. Temperatura de funcionamiento de 0ºC a 45ºC. Intervalo de humedad 5-95% de humedad relativa sin condensado. Grado de protección IP20.',31.00,'31','EUR','2025-12-24 21:09:43','2025-12-24 21:09:43','active',NULL,NULL,NULL,NULL,'obramat',NULL,NULL,NULL,NULL),(17,'https://www.obramat.es/productos/cronotermostato-semanal-orkli-10477740.html','CRONOTERMOSTATO SEMANAL ORKLI','Cronotermostato digital que permite la regulacion de temperatura tanto en calefaccion como refrigeracion diaria en intervalos de media hora, posibilidad de configurar dos tipos de temperatura en cada modo de funcionamiento (calefaccion o refrigeracion). Regulación de temperatura de 5ºC a 30ºC.',55.00,'55','EUR','2025-12-24 21:09:50','2025-12-24 21:09:50','active',NULL,NULL,NULL,NULL,'obramat',NULL,NULL,NULL,NULL),(18,'https://www.obramat.es/productos/monomando-fregadero-gerontologico-10572296.html','MONOMANDO FREGADERO GERONTOLOGICO','Los monomandos de cocina, tienen muchas ventajas: permiten una mayor precisión en la regulación del caudal de agua, ya que con un solo gesto vertical es posible ajustar la cantidad de acuerdo con las necesidades de cada aplicación, lo que permite al mismo tiempo ahorrar una gran cantidad de agua. Además de ser más cómodo y útil, evita las quemaduras.\nSus dos discos cerámicos que alberga en su interior y que incrementan la durabilidad del grifo, además de necesitar un menor mantenimiento que el resto de tipologías de grifos.',35.00,'35','EUR','2025-12-24 21:09:58','2025-12-24 21:09:58','active',NULL,NULL,NULL,NULL,'obramat',NULL,NULL,NULL,NULL),(19,'https://www.obramat.es/productos/monomando-fregadero-encimera-kirkland-10787770.html','MONOMANDO FREGADERO ENCIMERA KIRKLAND','Los monomandos de cocina, tienen muchas ventajas: permiten una mayor precisión en la regulación del caudal de agua, ya que con un solo gesto vertical es posible ajustar la cantidad de acuerdo con las necesidades de cada aplicación, lo que permite al mismo tiempo ahorrar una gran cantidad de agua. Además de ser más cómodo y útil, evita las quemaduras.\nSus dos discos cerámicos que alberga en su interior y que incrementan la durabilidad del grifo, además de necesitar un menor mantenimiento que el resto de tipologías de grifos.',38.00,'38','EUR','2025-12-24 21:10:06','2025-12-24 21:10:06','active',NULL,NULL,NULL,NULL,'obramat',NULL,NULL,NULL,NULL),(20,'https://www.obramat.es/productos/grifo-de-cocina-monomando-pvd-atlantis-inox-25094718.html','GRIFO DE COCINA MONOMANDO PVD ATLANTIS INOX','Grifo de cocina monomando, acabado inox, de caño alto. Medidas 350x205mm Ø50mm.Material: acero.Instalación: sobre encimera.Mecanismo: con cartucho de disco cerámico de Ø35mm.Tipo de apertura: simple.Aireador: simple.Ventajas del producto: grifo de cocina de caño alto, con un diseño moderno y sencillo.',69.00,'69','EUR','2025-12-24 21:10:14','2025-12-24 21:10:14','active',NULL,NULL,NULL,NULL,'obramat',NULL,NULL,NULL,NULL),(21,'https://www.obramat.es/productos/grifo-de-cocina-extraible-pvd-oro-cepillado-25098788.html','GRIFO DE COCINA EXTRAIBLE PVD ORO CEPILLADO','Grifo de cocina monomando, de la marca Corberó. modelo PVD oro cepillado. Fabricado en acero inoxidable. Acabado PVD. De caño alto. Material: Cuerpo y maneta en acero inoxidable.Instalación: sobre encimera o en fregadero.Cabezal extraible, de 2 funciones.Mecanismo: cartucho cerámico de Ø40 mm.Tipo de apertura: simple.',119.00,'119','EUR','2025-12-24 21:10:22','2025-12-24 21:10:22','active',NULL,NULL,NULL,NULL,'obramat',NULL,NULL,NULL,NULL),(22,'https://www.obramat.es/productos/monomando-fregadero-atis-l-roca-10834446.html','MONOMANDO FREGADERO ATIS L ROCA','Los monomandos de cocina, tienen muchas ventajas: permiten una mayor precisión en la regulación del caudal de agua, ya que con un solo gesto vertical es posible ajustar la cantidad de acuerdo con las necesidades de cada aplicación, lo que permite al mismo tiempo ahorrar una gran cantidad de agua. Además de ser más cómodo y útil, evita las quemaduras.\nSus dos discos cerámicos que alberga en su interior y que incrementan la durabilidad del grifo, además de necesitar un menor mantenimiento que el resto de tipologías de grifos.',87.00,'87','EUR','2025-12-24 21:10:29','2025-12-24 21:10:29','active',NULL,NULL,NULL,NULL,'obramat',NULL,NULL,NULL,NULL),(23,'https://www.obramat.es/productos/grifo-cocina-monomando-cano-bajo-talos-pro-25067475.html','GRIFO COCINA MONOMANDO CAÑO BAJO TALOS PRO','Grifo de cocina monomando de la marca Ramon Soler, modelo Talos pro, acabado cromo, de caño bajo. Medidas 230x145mm.Material: latón.Instalación: sobre encimera.Mecanismo: con cartucho de disco cerámico de Ø40mm.Tipo de apertura: dos posiciones.Aireador: standard.Ventajas del producto: grifo de cocina de caño bajo, con apertura de dos posiciones. De estilo sencillo.',36.00,'36','EUR','2025-12-24 21:10:37','2025-12-24 21:10:37','active',NULL,NULL,NULL,NULL,'obramat',NULL,NULL,NULL,NULL),(24,'https://www.obramat.es/productos/grifo-de-cocina-monomando-vulcano-10467261.html','GRIFO DE COCINA MONOMANDO VULCANO','Los monomandos de cocina, tienen muchas ventajas: permiten una mayor precisión en la regulación del caudal de agua, ya que con un solo gesto vertical es posible ajustar la cantidad de acuerdo con las necesidades de cada aplicación, lo que permite al mismo tiempo ahorrar una gran cantidad de agua. Además de ser más cómodo y útil, evita las quemaduras.\nSus dos discos cerámicos que alberga en su interior y que incrementan la durabilidad del grifo, además de necesitar un menor mantenimiento que el resto de tipologías de grifos.',48.00,'48','EUR','2025-12-24 21:10:45','2025-12-24 21:10:45','active',NULL,NULL,NULL,NULL,'obramat',NULL,NULL,NULL,NULL);
 /*!40000 ALTER TABLE `products` ENABLE KEYS */;
 UNLOCK TABLES;
 
@@ -221,4 +508,4 @@ UNLOCK TABLES;
 /*!40101 SET COLLATION_CONNECTION=@OLD_COLLATION_CONNECTION */;
 /*!40111 SET SQL_NOTES=@OLD_SQL_NOTES */;
 
--- Dump completed on 2025-12-26 17:31:40
+-- Dump completed on 2026-10-19 11:42:07
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// crawlLockName is the crawl_locks row shared by every scheduled job: all jobs
+// drive the same Chrome profile, so only one crawl may run at a time.
+const crawlLockName = "crawl"
+
+// crawlLockTTL is how long a lock stays valid without a heartbeat. A daemon
+// that dies mid-run releases the lock implicitly once it expires.
+const crawlLockTTL = 10 * time.Minute
+
+// scheduledJob is one entry of the schedule file.
+type scheduledJob struct {
+	Name         string `json:"name"`
+	Cron         string `json:"cron"`
+	URLsFile     string `json:"urls_file"`
+	SkipExisting bool   `json:"skip_existing"`
+
+	schedule *cronSchedule
+}
+
+type scheduleConfig struct {
+	Jobs []scheduledJob `json:"jobs"`
+}
+
+// loadSchedule reads and validates the JSON schedule file.
+func loadSchedule(path string) ([]scheduledJob, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg scheduleConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if len(cfg.Jobs) == 0 {
+		return nil, fmt.Errorf("no jobs defined in %s", path)
+	}
+	for i := range cfg.Jobs {
+		job := &cfg.Jobs[i]
+		if job.Name == "" {
+			return nil, fmt.Errorf("job %d: missing name", i)
+		}
+		if job.URLsFile == "" {
+			return nil, fmt.Errorf("job %s: missing urls_file", job.Name)
+		}
+		if job.schedule, err = parseCron(job.Cron); err != nil {
+			return nil, fmt.Errorf("job %s: %w", job.Name, err)
+		}
+		if job.schedule.next(time.Now()).IsZero() {
+			return nil, fmt.Errorf("job %s: cron %q never fires", job.Name, job.Cron)
+		}
+	}
+	return cfg.Jobs, nil
+}
+
+// runDaemon fires jobs on their cron schedules until ctx is cancelled. Jobs run
+// one at a time; a fire time that passes while another job is running is
+// skipped rather than queued. base holds the options shared by every job,
+// such as rotation and the artifacts directory.
+func runDaemon(ctx context.Context, db *sql.DB, jobs []scheduledJob, base crawlOptions) error {
+	owner := lockOwner()
+	next := make([]time.Time, len(jobs))
+	now := time.Now()
+	for i, job := range jobs {
+		next[i] = job.schedule.next(now)
+		slog.Info("scheduled job", "job", job.Name, "cron", job.Cron, "next_run", next[i].Format(time.RFC3339))
+	}
+
+	for {
+		// a zero time means the job has no run left; it is never armed
+		due := -1
+		for i := range next {
+			if !next[i].IsZero() && (due < 0 || next[i].Before(next[due])) {
+				due = i
+			}
+		}
+		if due < 0 {
+			slog.Warn("no scheduled job will run again, waiting for shutdown")
+			<-ctx.Done()
+			slog.Info("daemon shutting down")
+			return nil
+		}
+		timer := time.NewTimer(time.Until(next[due]))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			slog.Info("daemon shutting down")
+			return nil
+		case <-timer.C:
+		}
+
+		job := jobs[due]
+		if err := runScheduledJob(ctx, db, owner, job, base); err != nil {
+			slog.Error("job failed", "job", job.Name, "err", err)
+		}
+		if ctx.Err() != nil {
+			slog.Info("daemon shutting down")
+			return nil
+		}
+
+		now = time.Now()
+		next[due] = job.schedule.next(now)
+		for i := range next {
+			if i != due && !next[i].IsZero() && next[i].Before(now) {
+				slog.Warn("job missed its run, skipping", "job", jobs[i].Name, "missed_run", next[i].Format(time.RFC3339))
+				next[i] = jobs[i].schedule.next(now)
+			}
+		}
+		slog.Info("job rescheduled", "job", job.Name, "next_run", next[due].Format(time.RFC3339))
+	}
+}
+
+// runScheduledJob runs one crawl while holding the crawl lock.
+func runScheduledJob(ctx context.Context, db *sql.DB, owner string, job scheduledJob, base crawlOptions) error {
+	runID := newRunID()
+	lg := slog.With("job", job.Name, "run_id", runID)
+	urls, err := readURLList(job.URLsFile)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", job.URLsFile, err)
+	}
+	if len(urls) == 0 {
+		return fmt.Errorf("no URLs found in %s", job.URLsFile)
+	}
+
+	release, acquired, err := holdCrawlLock(db, owner, lg)
+	if err != nil {
+		return err
+	}
+	if !acquired {
+		lg.Info("job skipped: another crawl holds the lock")
+		return nil
+	}
+	defer release()
+
+	lg.Info("job started", "urls", len(urls))
+	start := time.Now()
+	opts := base
+	opts.SkipExisting = job.SkipExisting
+	opts.RunID = runID
+	err = crawlURLs(ctx, db, urls, opts)
+	lg.Info("job finished", "duration", time.Since(start).Round(time.Second).String())
+	return err
+}
+
+// holdCrawlLock takes the crawl lock and refreshes it until release is
+// called. acquired is false when another crawl holds the lock.
+func holdCrawlLock(db *sql.DB, owner string, lg *slog.Logger) (release func(), acquired bool, err error) {
+	acquired, err = AcquireCrawlLock(db, crawlLockName, owner, crawlLockTTL)
+	if err != nil {
+		return nil, false, fmt.Errorf("acquire lock: %w", err)
+	}
+	if !acquired {
+		return nil, false, nil
+	}
+
+	heartbeatCtx, stopHeartbeat := context.WithCancel(context.Background())
+	go func() {
+		ticker := time.NewTicker(crawlLockTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-heartbeatCtx.Done():
+				return
+			case <-ticker.C:
+				if err := RefreshCrawlLock(db, crawlLockName, owner, crawlLockTTL); err != nil {
+					lg.Warn("lock heartbeat failed", "err", err)
+				}
+			}
+		}
+	}()
+	return func() {
+		stopHeartbeat()
+		if err := ReleaseCrawlLock(db, crawlLockName, owner); err != nil {
+			lg.Error("release lock failed", "err", err)
+		}
+	}, true, nil
+}
+
+// lockOwner identifies this process in crawl_locks.
+func lockOwner() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// storeStock is the availability of a product in one physical store as
+// reported by the store availability API.
+type storeStock struct {
+	StoreID   string
+	StoreName string
+	City      string
+	// Quantity is nil when the API gives a status but no count.
+	Quantity *int
+	Status   string
+}
+
+var errNoStores = errors.New("no store entries found in stock response")
+
+// The availability API is not documented, so store entries are recognised by
+// field names rather than by a fixed schema. Keys are compared lower-cased.
+var (
+	storeIDKeys   = []string{"storeid", "store_id", "storecode", "store_code", "id", "code"}
+	storeNameKeys = []string{"storename", "store_name", "name", "label", "title"}
+	storeCityKeys = []string{"city", "storecity", "store_city", "town", "locality"}
+	stockQtyKeys  = []string{"stock", "quantity", "qty", "availablequantity", "available_quantity", "stocklevel", "stock_level", "availablestock"}
+	stockStatKeys = []string{"status", "stockstatus", "stock_status", "availability", "availabilitystatus", "label_stock", "message"}
+)
+
+// fetchResponseBody returns the body of a finished response in ctx's tab.
+func fetchResponseBody(ctx context.Context, id network.RequestID) ([]byte, error) {
+	var body []byte
+	err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		body, err = network.GetResponseBody(id).Do(ctx)
+		return err
+	}))
+	return body, err
+}
+
+// parseStoreStock extracts every store entry from a stock API JSON body.
+func parseStoreStock(body []byte) ([]storeStock, error) {
+	var doc any
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("stock response is not JSON: %w", err)
+	}
+	var stores []storeStock
+	collectStores(doc, "", &stores)
+	if len(stores) == 0 {
+		return nil, errNoStores
+	}
+	return stores, nil
+}
+
+// collectStores walks v depth-first and appends every object that looks like
+// a store entry. Nested "store" objects ({"store": {...}, "stock": 3}) are
+// flattened into their parent.
+func collectStores(v any, parentKey string, out *[]storeStock) {
+	switch v := v.(type) {
+	case []any:
+		for _, item := range v {
+			collectStores(item, parentKey, out)
+		}
+	case map[string]any:
+		if s, ok := storeFromObject(v, parentKey); ok {
+			*out = append(*out, s)
+			return
+		}
+		for k, child := range v {
+			collectStores(child, k, out)
+		}
+	}
+}
+
+func storeFromObject(obj map[string]any, parentKey string) (storeStock, bool) {
+	// Product objects carry id/name/stock too; never mistake them for stores.
+	if strings.Contains(strings.ToLower(parentKey), "product") {
+		return storeStock{}, false
+	}
+	fields := lowerKeys(obj)
+	// Merge a nested store description into the entry.
+	for _, k := range []string{"store", "shop", "pointofsale"} {
+		if nested, ok := fields[k].(map[string]any); ok {
+			for nk, nv := range lowerKeys(nested) {
+				if _, exists := fields[nk]; !exists || nk == "name" || nk == "id" {
+					fields[nk] = nv
+				}
+			}
+		}
+	}
+
+	var s storeStock
+	s.StoreID = firstString(fields, storeIDKeys)
+	s.StoreName = firstString(fields, storeNameKeys)
+	s.City = firstString(fields, storeCityKeys)
+	if addr, ok := fields["address"].(map[string]any); ok && s.City == "" {
+		s.City = firstString(lowerKeys(addr), storeCityKeys)
+	}
+	s.Quantity = firstInt(fields, stockQtyKeys)
+	s.Status = firstString(fields, stockStatKeys)
+
+	isStore := s.StoreName != "" || s.City != "" || strings.Contains(strings.ToLower(parentKey), "store")
+	hasStock := s.Quantity != nil || s.Status != ""
+	return s, isStore && hasStock && (s.StoreID != "" || s.StoreName != "")
+}
+
+func lowerKeys(obj map[string]any) map[string]any {
+	out := make(map[string]any, len(obj))
+	for k, v := range obj {
+		out[strings.ToLower(k)] = v
+	}
+	return out
+}
+
+func firstString(fields map[string]any, keys []string) string {
+	for _, k := range keys {
+		switch v := fields[k].(type) {
+		case string:
+			if s := strings.TrimSpace(v); s != "" {
+				return s
+			}
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		case map[string]any:
+			// e.g. {"status": {"code": "IN_STOCK", "label": "En stock"}}
+			if s := firstString(lowerKeys(v), []string{"label", "text", "code", "value"}); s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+func firstInt(fields map[string]any, keys []string) *int {
+	for _, k := range keys {
+		switch v := fields[k].(type) {
+		case float64:
+			n := int(math.Round(v))
+			return &n
+		case string:
+			if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+				return &n
+			}
+		case map[string]any:
+			if n := firstInt(lowerKeys(v), []string{"quantity", "value", "available", "qty"}); n != nil {
+				return n
+			}
+		}
+	}
+	return nil
+}
+
+// findStore returns the entry for city, matched on the city or store name.
+func findStore(stores []storeStock, city string) (storeStock, bool) {
+	c := strings.ToLower(city)
+	for _, s := range stores {
+		if strings.EqualFold(s.City, city) || strings.Contains(strings.ToLower(s.StoreName), c) {
+			return s, true
+		}
+	}
+	return storeStock{}, false
+}
+
+// dbNames returns the store_city and store_name used in product_availability,
+// keeping the "Obramat <store>" naming of rows written before the API was used.
+func (s storeStock) dbNames() (city, name string) {
+	name = s.StoreName
+	if name == "" {
+		name = s.City
+	}
+	if !strings.HasPrefix(strings.ToLower(name), "obramat") {
+		name = "Obramat " + name
+	}
+	city = s.City
+	if city == "" {
+		city = strings.TrimSpace(strings.TrimPrefix(name, "Obramat"))
+	}
+	return city, name
+}
+
+// availability converts an API entry into the structured model. The API's
+// exact quantity wins over any number found in its status text.
+func (s storeStock) availability() availability {
+	a := parseAvailability(s.Status)
+	if s.Quantity != nil {
+		a = a.withQuantity(*s.Quantity)
+	}
+	return a
+}
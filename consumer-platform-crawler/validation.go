@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// severity is what a failed validation rule does to a product.
+type severity string
+
+const (
+	severityOff    severity = "off"
+	severityWarn   severity = "warn"
+	severityReject severity = "reject"
+)
+
+// Validation rules. Image rules also clean the image list whenever they are
+// not off; their severity only decides how the finding is recorded.
+const (
+	rulePricePositive    = "price_positive"
+	ruleTitleNonEmpty    = "title_non_empty"
+	ruleDescription      = "description_non_empty"
+	ruleImageMedia       = "image_media"
+	ruleImageDedupe      = "image_dedupe"
+	ruleImagesPresent    = "images_present"
+	ruleReferencePresent = "reference_present"
+)
+
+// defaultValidationRules apply when no -validation file is given.
+var defaultValidationRules = validationRules{
+	rulePricePositive:    severityReject,
+	ruleTitleNonEmpty:    severityReject,
+	ruleDescription:      severityWarn,
+	ruleImageMedia:       severityWarn,
+	ruleImageDedupe:      severityWarn,
+	ruleImagesPresent:    severityWarn,
+	ruleReferencePresent: severityWarn,
+}
+
+// validationRules maps rule names to their severity.
+type validationRules map[string]severity
+
+// mediaAssetRe matches real product photos on the Adeo media CDN, e.g.
+// https://media.adeo.com/media/4515703/media.jpg, and captures the media id.
+var mediaAssetRe = regexp.MustCompile(`(?i)/media/(\d+)/[^/]+\.(jpe?g|png|webp)$`)
+
+// loadValidationRules reads {"rules": {"price_positive": "reject", ...}}.
+// Rules missing from the file keep their default severity.
+func loadValidationRules(path string) (validationRules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg struct {
+		Rules map[string]severity `json:"rules"`
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	rules := validationRules{}
+	for name, sev := range defaultValidationRules {
+		rules[name] = sev
+	}
+	for name, sev := range cfg.Rules {
+		if _, ok := defaultValidationRules[name]; !ok {
+			return nil, fmt.Errorf("%s: unknown rule %q", path, name)
+		}
+		switch sev {
+		case severityOff, severityWarn, severityReject:
+		default:
+			return nil, fmt.Errorf("%s: rule %s: severity %q, want off, warn or reject", path, name, sev)
+		}
+		rules[name] = sev
+	}
+	return rules, nil
+}
+
+// severityOf returns the configured severity; a nil set uses the defaults.
+func (r validationRules) severityOf(rule string) severity {
+	if r == nil {
+		r = defaultValidationRules
+	}
+	if s, ok := r[rule]; ok {
+		return s
+	}
+	return severityOff
+}
+
+// validationIssue is one finding about an extracted product.
+type validationIssue struct {
+	Rule     string
+	Severity severity
+	Message  string
+}
+
+// validationResult lists the findings for a product; Rejected is set if any
+// of them has reject severity.
+type validationResult struct {
+	Issues   []validationIssue
+	Rejected bool
+}
+
+func (v *validationResult) add(rules validationRules, rule, format string, args ...any) {
+	sev := rules.severityOf(rule)
+	if sev == severityOff {
+		return
+	}
+	v.Issues = append(v.Issues, validationIssue{Rule: rule, Severity: sev, Message: fmt.Sprintf(format, args...)})
+	if sev == severityReject {
+		v.Rejected = true
+	}
+}
+
+// rejectReasons joins the messages of the rejecting issues.
+func (v validationResult) rejectReasons() string {
+	var reasons []string
+	for _, i := range v.Issues {
+		if i.Severity == severityReject {
+			reasons = append(reasons, i.Rule+": "+i.Message)
+		}
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// validateProduct checks p before it is persisted and cleans its image list
+// in place: non-media images are dropped and variants of the same media id
+// are collapsed to the first one.
+func validateProduct(p *productData, rules validationRules) validationResult {
+	var v validationResult
+
+	if p.PriceNumeric <= 0 {
+		v.add(rules, rulePricePositive, "price %.2f parsed from %q", p.PriceNumeric, p.PriceText)
+	}
+	if strings.TrimSpace(p.Title) == "" {
+		v.add(rules, ruleTitleNonEmpty, "empty title")
+	}
+	if strings.TrimSpace(p.Description) == "" {
+		v.add(rules, ruleDescription, "empty description")
+	}
+	if p.Reference == "" {
+		v.add(rules, ruleReferencePresent, "no retailer reference in URL or page")
+	}
+
+	checkMedia := rules.severityOf(ruleImageMedia) != severityOff
+	dedupe := rules.severityOf(ruleImageDedupe) != severityOff
+	seen := map[string]bool{}
+	var kept, dropped, dupes []string
+	for _, img := range p.CarouselImages {
+		m := mediaAssetRe.FindStringSubmatch(img)
+		if m == nil {
+			if checkMedia {
+				dropped = append(dropped, img)
+				continue
+			}
+			kept = append(kept, img)
+			continue
+		}
+		if dedupe && seen[m[1]] {
+			dupes = append(dupes, img)
+			continue
+		}
+		seen[m[1]] = true
+		kept = append(kept, img)
+	}
+	if len(dropped) > 0 {
+		v.add(rules, ruleImageMedia, "dropped %d non-media images: %s", len(dropped), strings.Join(dropped, ", "))
+	}
+	if len(dupes) > 0 {
+		v.add(rules, ruleImageDedupe, "dropped %d duplicate media variants: %s", len(dupes), strings.Join(dupes, ", "))
+	}
+	p.CarouselImages = kept
+	if len(kept) == 0 {
+		v.add(rules, ruleImagesPresent, "no product images")
+	}
+
+	sort.SliceStable(v.Issues, func(i, j int) bool {
+		return v.Issues[i].Severity == severityReject && v.Issues[j].Severity != severityReject
+	})
+	return v
+}
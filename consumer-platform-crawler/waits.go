@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+)
+
+// networkTracker counts the in-flight requests of a tab. It must be attached
+// before the navigation it is meant to observe.
+type networkTracker struct {
+	mu           sync.Mutex
+	inflight     map[network.RequestID]struct{}
+	lastActivity time.Time
+}
+
+func trackNetwork(ctx context.Context) *networkTracker {
+	t := &networkTracker{inflight: map[network.RequestID]struct{}{}, lastActivity: time.Now()}
+	chromedp.ListenTarget(ctx, func(ev any) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		switch ev := ev.(type) {
+		case *network.EventRequestWillBeSent:
+			t.inflight[ev.RequestID] = struct{}{}
+		case *network.EventLoadingFinished:
+			delete(t.inflight, ev.RequestID)
+		case *network.EventLoadingFailed:
+			delete(t.inflight, ev.RequestID)
+		default:
+			return
+		}
+		t.lastActivity = time.Now()
+	})
+	return t
+}
+
+// waitIdle returns once at most maxInflight requests have been pending for a
+// continuous quiet period. A small maxInflight tolerates analytics beacons and
+// long-polling connections that never finish.
+func (t *networkTracker) waitIdle(ctx context.Context, quiet time.Duration, maxInflight int) error {
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	var idleSince time.Time
+	for {
+		t.mu.Lock()
+		n := len(t.inflight)
+		last := t.lastActivity
+		t.mu.Unlock()
+		if n <= maxInflight {
+			if idleSince.IsZero() || idleSince.Before(last) {
+				idleSince = time.Now()
+			}
+			if time.Since(idleSince) >= quiet {
+				return nil
+			}
+		} else {
+			idleSince = time.Time{}
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("network idle wait: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// waitDOMSettled resolves once no DOM mutation has happened for quiet, or after
+// max regardless (autoplaying carousels never settle).
+func waitDOMSettled(quiet, max time.Duration) chromedp.Action {
+	script := fmt.Sprintf(`new Promise(resolve => {
+		let timer;
+		const done = () => { obs.disconnect(); clearTimeout(cap); resolve(true); };
+		const obs = new MutationObserver(() => { clearTimeout(timer); timer = setTimeout(done, %[1]d); });
+		const cap = setTimeout(done, %[2]d);
+		obs.observe(document.documentElement, {childList: true, subtree: true, attributes: true, characterData: true});
+		timer = setTimeout(done, %[1]d);
+	})`, quiet.Milliseconds(), max.Milliseconds())
+	var settled bool
+	return chromedp.Evaluate(script, &settled, func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+		return p.WithAwaitPromise(true)
+	})
+}
+
+// waitTextNonEmpty polls until the first element matching sel has non-blank
+// innerText, and stores that text in text.
+func waitTextNonEmpty(sel string, text *string) chromedp.Action {
+	script := fmt.Sprintf(`(() => {
+		const el = document.querySelector(%s);
+		const t = el ? el.innerText.trim() : '';
+		return t.length > 0 ? t : null;
+	})()`, strconv.Quote(sel))
+	return chromedp.Poll(script, text, chromedp.WithPollingInterval(100*time.Millisecond))
+}
+
+// capturedResponse is a network response whose body has finished loading.
+type capturedResponse struct {
+	RequestID network.RequestID
+	URL       string
+	Status    int64
+	MimeType  string
+}
+
+// responseWaiter captures the first response whose URL satisfies match. Like
+// networkTracker it must be created before the action that triggers the
+// request.
+type responseWaiter struct {
+	done chan capturedResponse
+}
+
+func awaitResponse(ctx context.Context, match func(url string) bool) *responseWaiter {
+	w := &responseWaiter{done: make(chan capturedResponse, 1)}
+	var mu sync.Mutex
+	pending := map[network.RequestID]capturedResponse{}
+	chromedp.ListenTarget(ctx, func(ev any) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch ev := ev.(type) {
+		case *network.EventResponseReceived:
+			if ev.Response != nil && match(ev.Response.URL) {
+				pending[ev.RequestID] = capturedResponse{
+					RequestID: ev.RequestID,
+					URL:       ev.Response.URL,
+					Status:    ev.Response.Status,
+					MimeType:  ev.Response.MimeType,
+				}
+			}
+		case *network.EventLoadingFinished:
+			if r, ok := pending[ev.RequestID]; ok {
+				delete(pending, ev.RequestID)
+				select {
+				case w.done <- r:
+				default:
+				}
+			}
+		}
+	})
+	return w
+}
+
+// wait blocks until a matching response has finished loading.
+func (w *responseWaiter) wait(ctx context.Context) (capturedResponse, error) {
+	select {
+	case r := <-w.done:
+		return r, nil
+	case <-ctx.Done():
+		return capturedResponse{}, fmt.Errorf("response wait: %w", ctx.Err())
+	}
+}
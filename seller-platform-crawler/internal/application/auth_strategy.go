@@ -0,0 +1,62 @@
+package application
+
+import (
+	"fmt"
+
+	"seller-platform-crawler/internal/domain/ports"
+	"seller-platform-crawler/internal/infrastructure/browser"
+)
+
+// Login strategies selectable with AuthConfig.Strategy
+const (
+	AuthGoogle   = "google"
+	AuthPassword = "password"
+	AuthCookies  = "cookies"
+)
+
+// AuthConfig selects and configures a login strategy
+type AuthConfig struct {
+	Strategy    string
+	Credentials ports.Credentials
+	// Google applies to the google strategy. Credentials.GoogleAccount fills
+	// in Google.Account when it is empty
+	Google GoogleLoginOptions
+	// CookieSource is the cookie file imported by the cookies strategy
+	CookieSource ports.SessionStore
+	// Store persists the session between runs; nil disables it
+	Store ports.SessionStore
+}
+
+// NewAuthService builds the login strategy named in cfg
+func NewAuthService(browserAdapter *browser.ChromeDPAdapter, cfg AuthConfig) (ports.AuthService, error) {
+	switch cfg.Strategy {
+	case AuthGoogle, "":
+		s := NewWallapopAuthService(browserAdapter)
+		opts := cfg.Google
+		if opts.Account == "" {
+			opts.Account = cfg.Credentials.GoogleAccount
+		}
+		if opts.ManualTimeout == 0 {
+			opts.ManualTimeout = s.google.ManualTimeout
+		}
+		s.SetGoogleOptions(opts)
+		s.SetSessionStore(cfg.Store)
+		return s, nil
+	case AuthPassword:
+		s, err := NewPasswordAuthService(browserAdapter, cfg.Credentials)
+		if err != nil {
+			return nil, err
+		}
+		s.SetSessionStore(cfg.Store)
+		return s, nil
+	case AuthCookies:
+		if cfg.CookieSource == nil {
+			return nil, fmt.Errorf("cookies login needs a cookie file")
+		}
+		s := NewCookieAuthService(browserAdapter, cfg.CookieSource)
+		s.SetSessionStore(cfg.Store)
+		return s, nil
+	default:
+		return nil, fmt.Errorf("unknown auth strategy %q, want %s, %s or %s", cfg.Strategy, AuthGoogle, AuthPassword, AuthCookies)
+	}
+}
@@ -0,0 +1,122 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"seller-platform-crawler/internal/domain/ports"
+)
+
+// BatchOptions controls the pace of a bulk upload
+type BatchOptions struct {
+	// MaxItems stops the batch after that many uploads; 0 drains the queue
+	MaxItems int
+	// MinGap and MaxGap bound the random pause between two uploads
+	MinGap time.Duration
+	MaxGap time.Duration
+	// MaxConsecutiveFailures stops the batch when uploads keep failing,
+	// e.g. after Wallapop starts rejecting the account
+	MaxConsecutiveFailures int
+}
+
+// DefaultBatchOptions spaces uploads like a person listing items by hand
+var DefaultBatchOptions = BatchOptions{
+	MinGap:                 45 * time.Second,
+	MaxGap:                 2 * time.Minute,
+	MaxConsecutiveFailures: 3,
+}
+
+// BatchResult counts the outcome of a batch
+type BatchResult struct {
+	Published int
+	Failed    int
+}
+
+// BulkPublisher works through the listing job queue with a ListingPublisher
+type BulkPublisher struct {
+	jobs      ports.ListingJobRepository
+	publisher *ListingPublisher
+	browser   ports.BrowserAutomation
+}
+
+// NewBulkPublisher creates a bulk publisher. The browser paces the uploads
+func NewBulkPublisher(jobs ports.ListingJobRepository, publisher *ListingPublisher, browser ports.BrowserAutomation) *BulkPublisher {
+	return &BulkPublisher{jobs: jobs, publisher: publisher, browser: browser}
+}
+
+// Run uploads pending jobs until the queue is empty, MaxItems is reached or
+// ctx is cancelled. A failed upload is recorded on its job and does not stop
+// the batch, unless the session expired or too many failed in a row
+func (b *BulkPublisher) Run(ctx context.Context, opts BatchOptions) (BatchResult, error) {
+	var res BatchResult
+	// an upload takes at most its photo downloads plus publishTimeout, so a
+	// job uploading for twice that has lost its worker
+	if n, err := b.jobs.FailInterrupted(ctx, 2*publishTimeout); err != nil {
+		return res, err
+	} else if n > 0 {
+		slog.Warn("Jobs interrupted by a previous run marked failed, check Wallapop before retrying them", "step", "bulk_publish", "jobs", n)
+	}
+
+	failuresInRow := 0
+	for opts.MaxItems == 0 || res.Published+res.Failed < opts.MaxItems {
+		if res.Published+res.Failed > 0 {
+			// pace before claiming so a cancelled batch leaves the job pending
+			if err := b.browser.Pause(ctx, int(opts.MinGap.Milliseconds()), int(opts.MaxGap.Milliseconds())); err != nil {
+				return res, err
+			}
+		}
+		job, err := b.jobs.Claim(ctx)
+		if err != nil {
+			return res, fmt.Errorf("claim job: %w", err)
+		}
+		if job == nil {
+			break
+		}
+
+		lg := slog.With("step", "bulk_publish", "job_id", job.ID, "product_id", job.ProductID, "attempt", job.Attempts)
+		lg.Info("Uploading product")
+		listing, err := b.publisher.Publish(ctx, job.ProductID)
+		if err != nil {
+			res.Failed++
+			failuresInRow++
+			lg.Warn("Upload failed", "err", err)
+			mark := b.jobs.MarkFailed
+			if errors.Is(err, ErrUploadUnconfirmed) {
+				// only -retry-interrupted re-queues it, after a look at Wallapop
+				mark = b.jobs.MarkInterrupted
+			}
+			if markErr := mark(context.Background(), job.ID, err.Error()); markErr != nil {
+				return res, markErr
+			}
+			if errors.Is(err, ports.ErrSessionExpired) {
+				return res, err
+			}
+			if opts.MaxConsecutiveFailures > 0 && failuresInRow >= opts.MaxConsecutiveFailures {
+				return res, fmt.Errorf("stopping after %d failed uploads in a row: %w", failuresInRow, err)
+			}
+			continue
+		}
+		failuresInRow = 0
+		res.Published++
+		if err := b.jobs.MarkPublished(context.Background(), job.ID, listing); err != nil {
+			return res, fmt.Errorf("record listing %s of job %d: %w", listing.ID, job.ID, err)
+		}
+		lg.Info("Upload recorded", "listing_id", listing.ID)
+	}
+	slog.Info("Batch finished", "step", "bulk_publish", "published", res.Published, "failed", res.Failed)
+	return res, nil
+}
+
+// Enqueue queues products for upload. Products already queued, published or
+// failed keep their job
+func (b *BulkPublisher) Enqueue(ctx context.Context, productIDs []int64) (int, error) {
+	return b.jobs.Enqueue(ctx, productIDs)
+}
+
+// RetryFailed re-queues failed jobs; see ports.ListingJobRepository
+func (b *BulkPublisher) RetryFailed(ctx context.Context, includeInterrupted bool) (int, error) {
+	return b.jobs.RetryFailed(ctx, includeInterrupted)
+}
@@ -0,0 +1,62 @@
+package application
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"seller-platform-crawler/internal/domain/model"
+)
+
+// CategoryMap picks the Wallapop category of a product from its Obramat
+// breadcrumb. The deepest crumb with an entry wins, so "Taladros" can map
+// elsewhere than the "Herramientas" above it
+type CategoryMap struct {
+	// Default is used when no crumb has an entry, e.g. the -category flag
+	Default string
+	crumbs  map[string]string
+}
+
+// NewCategoryMap creates a map sending every product to def
+func NewCategoryMap(def string) CategoryMap {
+	return CategoryMap{Default: def}
+}
+
+// LoadCategoryMap reads a JSON object from Obramat category names to
+// Wallapop categories, e.g. {"Jardín": "Hogar y jardín"}. Names match
+// case-insensitively
+func LoadCategoryMap(path, def string) (CategoryMap, error) {
+	m := NewCategoryMap(def)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return m, err
+	}
+	var entries map[string]string
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return m, fmt.Errorf("parse %s: %w", path, err)
+	}
+	m.crumbs = make(map[string]string, len(entries))
+	for crumb, category := range entries {
+		if strings.TrimSpace(category) == "" {
+			return m, fmt.Errorf("%s: no Wallapop category for %q", path, crumb)
+		}
+		m.crumbs[categoryKey(crumb)] = strings.TrimSpace(category)
+	}
+	return m, nil
+}
+
+// For returns the Wallapop category of product
+func (m CategoryMap) For(product *model.Product) string {
+	crumbs := strings.Split(product.Category, ">")
+	for i := len(crumbs) - 1; i >= 0; i-- {
+		if category, ok := m.crumbs[categoryKey(crumbs[i])]; ok {
+			return category
+		}
+	}
+	return m.Default
+}
+
+func categoryKey(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
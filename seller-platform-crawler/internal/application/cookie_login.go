@@ -0,0 +1,62 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"seller-platform-crawler/internal/domain/ports"
+	"seller-platform-crawler/internal/infrastructure/browser"
+)
+
+// CookieAuthService logs in by importing cookies exported from a browser
+// where the account is already signed in. It never fills in a form: when the
+// imported cookies are not accepted it returns ErrSessionExpired
+type CookieAuthService struct {
+	wallapopSession
+	source ports.SessionStore
+}
+
+var _ ports.AuthService = (*CookieAuthService)(nil)
+
+// NewCookieAuthService creates a login that imports the cookies of source
+func NewCookieAuthService(browserAdapter *browser.ChromeDPAdapter, source ports.SessionStore) *CookieAuthService {
+	return &CookieAuthService{
+		wallapopSession: wallapopSession{browser: browserAdapter},
+		source:          source,
+	}
+}
+
+// Login reuses the stored session if it is valid, otherwise imports the
+// cookie file and checks again
+func (s *CookieAuthService) Login(ctx context.Context) error {
+	if err := s.ensureBrowser(); err != nil {
+		return err
+	}
+	tabCtx, cancel := s.browser.CreateTabContext(45 * time.Second)
+	defer cancel()
+
+	if valid, err := s.resumeSession(tabCtx); valid || err != nil {
+		return err
+	}
+
+	cookies, err := s.source.Load()
+	if err != nil {
+		return fmt.Errorf("read cookie file: %w", err)
+	}
+	if len(cookies) == 0 {
+		return fmt.Errorf("%w: cookie file is empty or missing", ports.ErrSessionExpired)
+	}
+	if err := s.browser.ImportCookies(tabCtx, cookies); err != nil {
+		return fmt.Errorf("import cookies: %w", err)
+	}
+	slog.Info("Imported cookies from file", "step", "cookie_login", "cookies", len(cookies))
+
+	if err := s.checkSession(tabCtx); err != nil {
+		return fmt.Errorf("imported cookies were not accepted: %w", err)
+	}
+	s.saveSession(tabCtx)
+	slog.Info("Login process completed successfully", "step", "login")
+	return nil
+}
@@ -0,0 +1,238 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"seller-platform-crawler/internal/domain/ports"
+)
+
+// defaultCategory keys the template used when a category has none
+const defaultCategory = "default"
+
+// defaultDescriptionTemplate is used unless a template directory replaces it
+const defaultDescriptionTemplate = `{{.Title}}{{with .Brand}} de {{.}}{{end}}{{with .Condition}}, {{lower .}}{{end}}.
+{{with .Summary}}
+{{.}}
+{{end}}
+{{- with .Specs}}
+Características:
+{{range first 8 .}}- {{.Name}}: {{.Value}}
+{{end}}{{end}}
+Precio: {{price .Price}} €{{with .Reference}} · Ref. {{.}}{{end}}
+Envío disponible o entrega en mano.`
+
+// descriptionData is what the templates see
+type descriptionData struct {
+	Title     string
+	Brand     string
+	Model     string
+	Reference string
+	EAN       string
+	// Summary is the product description from the product page, shortened
+	// to half the length limit
+	Summary   string
+	Specs     []specData
+	Price     float64
+	Condition string
+	Category  string
+	Keywords  []string
+}
+
+type specData struct {
+	Name  string
+	Value string
+}
+
+var descriptionFuncs = template.FuncMap{
+	"price": func(p float64) string {
+		return strings.Replace(fmt.Sprintf("%.2f", p), ".", ",", 1)
+	},
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"trunc": truncateText,
+	"first": func(n int, specs []specData) []specData {
+		return specs[:min(n, len(specs))]
+	},
+}
+
+// TemplateDescriptions writes descriptions from text/template templates, one
+// per Wallapop category, and ends them with hashtags
+type TemplateDescriptions struct {
+	templates map[string]*template.Template
+	keywords  []string
+}
+
+// NewTemplateDescriptions loads the *.tmpl files of dir, named after the
+// Wallapop category they serve (bricolaje.tmpl), which each product gets
+// from its Obramat category, or default.tmpl. With an empty dir
+// only the built-in template is used. keywords become hashtags of every
+// description, after the category and the brand
+func NewTemplateDescriptions(dir string, keywords []string) (*TemplateDescriptions, error) {
+	t := &TemplateDescriptions{templates: map[string]*template.Template{}, keywords: keywords}
+	def, err := template.New(defaultCategory).Funcs(descriptionFuncs).Parse(defaultDescriptionTemplate)
+	if err != nil {
+		return nil, err
+	}
+	t.templates[defaultCategory] = def
+	if dir == "" {
+		return t, nil
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no .tmpl files in %s", dir)
+	}
+	for _, f := range files {
+		src, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		name := strings.ToLower(strings.TrimSuffix(filepath.Base(f), ".tmpl"))
+		tmpl, err := template.New(name).Funcs(descriptionFuncs).Parse(string(src))
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", f, err)
+		}
+		t.templates[name] = tmpl
+	}
+	return t, nil
+}
+
+var _ ports.DescriptionGenerator = (*TemplateDescriptions)(nil)
+
+// Generate renders the category's template and adds as many hashtags as fit
+// in req.MaxLength
+func (t *TemplateDescriptions) Generate(ctx context.Context, req ports.DescriptionRequest) (string, error) {
+	p := req.Product
+	if req.MaxLength <= 0 {
+		req.MaxLength = maxDescriptionLength
+	}
+	tmpl, ok := t.templates[strings.ToLower(strings.TrimSpace(req.Category))]
+	if !ok {
+		tmpl = t.templates[defaultCategory]
+	}
+	data := descriptionData{
+		Title:     strings.Join(strings.Fields(p.Title), " "),
+		Brand:     p.Brand,
+		Model:     p.Model,
+		Reference: p.Reference,
+		EAN:       p.EAN,
+		// leave room for the specs and the price
+		Summary:   truncateText(strings.TrimSpace(p.Description), req.MaxLength/2),
+		Price:     req.Price,
+		Condition: req.Condition,
+		Category:  req.Category,
+		Keywords:  t.keywords,
+	}
+	for _, s := range p.Specs {
+		data.Specs = append(data.Specs, specData{Name: s.Name, Value: s.Value})
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("template %s: %w", tmpl.Name(), err)
+	}
+	body := tidyLines(buf.String())
+	tags := hashtags(append([]string{req.Category, p.Brand}, t.keywords...))
+	return fitDescription(body, tags, req.MaxLength), nil
+}
+
+// tidyLines trims every line and folds runs of blank lines
+func tidyLines(s string) string {
+	var out []string
+	blank := false
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			blank = len(out) > 0
+			continue
+		}
+		if blank {
+			out = append(out, "")
+			blank = false
+		}
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
+
+// hashtags turns words into unique #tags, dropping spaces and punctuation
+func hashtags(words []string) []string {
+	seen := map[string]bool{}
+	var tags []string
+	for _, w := range words {
+		tag := strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return unicode.ToLower(r)
+			}
+			return -1
+		}, w)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, "#"+tag)
+	}
+	return tags
+}
+
+// fitDescription keeps body within max characters and appends the hashtags
+// that still fit, in order
+func fitDescription(body string, tags []string, max int) string {
+	body = truncateText(body, max)
+	n := len([]rune(body))
+	var line []string
+	for _, tag := range tags {
+		// a blank line before the first tag, a space before the others
+		sep := 1
+		if len(line) == 0 {
+			sep = 2
+		}
+		if n+sep+len([]rune(tag)) > max {
+			break
+		}
+		n += sep + len([]rune(tag))
+		line = append(line, tag)
+	}
+	if len(line) == 0 {
+		return body
+	}
+	return body + "\n\n" + strings.Join(line, " ")
+}
+
+// FallbackDescriptions tries one generator and falls back to another when
+// it fails, e.g. an LLM service with the templates behind it
+type FallbackDescriptions struct {
+	primary  ports.DescriptionGenerator
+	fallback ports.DescriptionGenerator
+}
+
+// NewFallbackDescriptions creates a generator that uses fallback when
+// primary fails
+func NewFallbackDescriptions(primary, fallback ports.DescriptionGenerator) *FallbackDescriptions {
+	return &FallbackDescriptions{primary: primary, fallback: fallback}
+}
+
+var _ ports.DescriptionGenerator = (*FallbackDescriptions)(nil)
+
+// Generate returns the primary description, or the fallback one
+func (f *FallbackDescriptions) Generate(ctx context.Context, req ports.DescriptionRequest) (string, error) {
+	text, err := f.primary.Generate(ctx, req)
+	if err == nil && strings.TrimSpace(text) != "" {
+		return text, nil
+	}
+	if err == nil {
+		err = errors.New("empty description")
+	}
+	slog.Warn("Description generator failed, using the fallback", "step", "description", "product_id", req.Product.ID, "err", err)
+	return f.fallback.Generate(ctx, req)
+}
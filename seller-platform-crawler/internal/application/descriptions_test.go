@@ -0,0 +1,134 @@
+package application
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"seller-platform-crawler/internal/domain/model"
+	"seller-platform-crawler/internal/domain/ports"
+)
+
+func TestTidyLines(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "trims lines", in: "  Taladro  \n\tPercutor ", want: "Taladro\nPercutor"},
+		{name: "folds blank runs", in: "Taladro\n\n \n\nPercutor", want: "Taladro\n\nPercutor"},
+		{name: "drops leading and trailing blanks", in: "\n\n Taladro\n\n\n", want: "Taladro"},
+		{name: "empty", in: " \n ", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tidyLines(tt.in); got != tt.want {
+				t.Errorf("tidyLines(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHashtags(t *testing.T) {
+	tests := []struct {
+		name  string
+		words []string
+		want  []string
+	}{
+		{name: "lowercases", words: []string{"Bosch"}, want: []string{"#bosch"}},
+		{name: "drops spaces and punctuation", words: []string{"Hogar y jardín", "Black+Decker", "18V."}, want: []string{"#hogaryjardín", "#blackdecker", "#18v"}},
+		{name: "dedupes", words: []string{"Bosch", "bosch", "BOSCH!"}, want: []string{"#bosch"}},
+		{name: "skips empty words", words: []string{"", "--", "Makita"}, want: []string{"#makita"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hashtags(tt.words); !slices.Equal(got, tt.want) {
+				t.Errorf("hashtags(%q) = %q, want %q", tt.words, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFitDescription(t *testing.T) {
+	tags := []string{"#bricolaje", "#bosch"}
+	tests := []struct {
+		name string
+		body string
+		max  int
+		want string
+	}{
+		{name: "all tags fit", body: "Taladro", max: 100, want: "Taladro\n\n#bricolaje #bosch"},
+		// 7 + 2 + 10 = 19, the second tag needs 7 more
+		{name: "only the first tag fits", body: "Taladro", max: 25, want: "Taladro\n\n#bricolaje"},
+		{name: "exactly at the limit", body: "Taladro", max: 26, want: "Taladro\n\n#bricolaje #bosch"},
+		{name: "no tag fits", body: "Taladro", max: 18, want: "Taladro"},
+		{name: "tags keep their order", body: "Taladro", max: 19, want: "Taladro\n\n#bricolaje"},
+		{name: "long body is cut", body: "Taladro percutor sin cable", max: 20, want: "Taladro percutor…"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fitDescription(tt.body, tags, tt.max)
+			if got != tt.want {
+				t.Errorf("fitDescription(%q, %d) = %q, want %q", tt.body, tt.max, got, tt.want)
+			}
+			if n := len([]rune(got)); n > tt.max {
+				t.Errorf("fitDescription(%q, %d) is %d characters", tt.body, tt.max, n)
+			}
+		})
+	}
+}
+
+func TestTemplateDescriptionsGenerate(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "default.tmpl"), []byte("{{.Title}}\n\n\n{{.Summary}}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "bricolaje.tmpl"), []byte("Bricolaje: {{.Title}}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gen, err := NewTemplateDescriptions(dir, []string{"oferta"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	product := &model.Product{
+		Title:       "Taladro   percutor",
+		Brand:       "Bosch",
+		Description: strings.Repeat("Potente y ligero. ", 60),
+	}
+
+	tests := []struct {
+		name      string
+		category  string
+		maxLength int
+		want      string
+	}{
+		{name: "category template", category: "Bricolaje", want: "Bricolaje: Taladro percutor\n\n#bricolaje #bosch #oferta"},
+		{name: "category names match case-insensitively", category: " BRICOLAJE ", want: "Bricolaje: Taladro percutor\n\n#bricolaje #bosch #oferta"},
+		{name: "short limit drops hashtags", category: "Bricolaje", maxLength: 40, want: "Bricolaje: Taladro percutor\n\n#bricolaje"},
+		{name: "default template", category: "Jardinería", maxLength: 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := gen.Generate(context.Background(), ports.DescriptionRequest{Product: product, Category: tt.category, MaxLength: tt.maxLength})
+			if err != nil {
+				t.Fatal(err)
+			}
+			max := tt.maxLength
+			if max == 0 {
+				max = maxDescriptionLength
+			}
+			if n := len([]rune(got)); n > max {
+				t.Errorf("description is %d characters, limit %d:\n%s", n, max, got)
+			}
+			if tt.want != "" && got != tt.want {
+				t.Errorf("description = %q, want %q", got, tt.want)
+			}
+			if tt.want == "" && !strings.HasPrefix(got, "Taladro percutor\n\nPotente y ligero.") {
+				t.Errorf("description = %q, want the default template", got)
+			}
+		})
+	}
+}
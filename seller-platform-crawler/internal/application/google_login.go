@@ -0,0 +1,209 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrManualActionRequired is returned when Google asks for something the
+// automated flow cannot do, e.g. a password or a 2FA challenge, and manual
+// assist is off
+var ErrManualActionRequired = errors.New("google sign-in needs manual action")
+
+const (
+	googleAccountsHost = "accounts.google.com"
+	// popupOpenTimeout is how long to wait for the popup after the click
+	popupOpenTimeout = 15 * time.Second
+	// popupStepTimeout bounds the automated part of the popup flow
+	popupStepTimeout = 60 * time.Second
+	// redirectTimeout is how long Wallapop gets to finish the login once the
+	// popup has closed
+	redirectTimeout = 30 * time.Second
+)
+
+// GoogleLoginOptions configures the Google popup flow
+type GoogleLoginOptions struct {
+	// Account is the email to pick in the account chooser. Empty picks the
+	// first signed-in account
+	Account string
+	// ManualAssist pauses the flow when Google asks for a password, 2FA or a
+	// captcha so a human can finish it in the visible browser window
+	ManualAssist bool
+	// ManualTimeout is how long to wait for the human
+	ManualTimeout time.Duration
+}
+
+// googlePopupStateScript classifies the page shown in the Google popup
+const googlePopupStateScript = `
+	(function() {
+		const href = location.href;
+		const visible = el => !!el && el.offsetParent !== null;
+		if (/\/challenge\/|\/signin\/rejected|\/v3\/signin\/challenge/.test(href)) return 'challenge';
+		if (document.querySelector('iframe[src*="recaptcha"], #captchaimg')) return 'challenge';
+		if (visible(document.querySelector('input[type="password"]'))) return 'password';
+		if (visible(document.querySelector('#identifierId, input[type="email"]'))) return 'email';
+		if (document.querySelector('[data-identifier], [data-email]')) return 'chooser';
+		const consent = /^(continuar|continue|confirmar|confirm|permitir|allow)$/i;
+		for (const b of document.querySelectorAll('button, div[role="button"]')) {
+			if (consent.test((b.innerText || '').trim())) return 'consent';
+		}
+		if (document.querySelector('#submit_approve_access')) return 'consent';
+		return 'loading';
+	})()
+`
+
+// chooseAccountScript clicks the configured account, or the first one
+const chooseAccountScript = `
+	(function(account) {
+		const items = [...document.querySelectorAll('[data-identifier], [data-email]')];
+		const match = items.find(el => {
+			const id = (el.getAttribute('data-identifier') || el.getAttribute('data-email') || '').toLowerCase();
+			return account === '' || id === account;
+		});
+		if (!match) return false;
+		match.click();
+		return true;
+	})(%q)
+`
+
+// consentScript clicks the button that grants Wallapop access
+const consentScript = `
+	(function() {
+		const consent = /^(continuar|continue|confirmar|confirm|permitir|allow)$/i;
+		for (const b of document.querySelectorAll('#submit_approve_access, button, div[role="button"]')) {
+			if (b.id === 'submit_approve_access' || consent.test((b.innerText || '').trim())) {
+				b.click();
+				return true;
+			}
+		}
+		return false;
+	})()
+`
+
+// SetGoogleOptions configures account selection and manual assist for the
+// Google login
+func (s *WallapopAuthService) SetGoogleOptions(opts GoogleLoginOptions) {
+	s.google = opts
+}
+
+// loginTimeout is the budget of the whole login tab, which includes the time
+// a human may need in manual assist mode
+func (s *WallapopAuthService) loginTimeout() time.Duration {
+	d := 45*time.Second + popupStepTimeout + redirectTimeout
+	if s.google.ManualAssist {
+		d += s.google.ManualTimeout
+	}
+	return d
+}
+
+// driveGooglePopup steps through account selection and consent in the popup
+// tab. It returns once the popup closes itself, which Google does after
+// handing the credential to Wallapop
+func (s *WallapopAuthService) driveGooglePopup(popupCtx context.Context) error {
+	account := strings.ToLower(strings.TrimSpace(s.google.Account))
+	deadline := time.Now().Add(popupStepTimeout)
+	var manualUntil time.Time
+	var last string
+
+	for {
+		var stage string
+		err := s.browser.ExecuteScript(popupCtx, googlePopupStateScript, &stage)
+		if popupCtx.Err() != nil {
+			// the popup closes itself once Google hands over the credential
+			slog.Info("Google popup closed", "step", "google_login")
+			return nil
+		}
+		if err != nil {
+			// the page may be mid-navigation
+			stage = "loading"
+		}
+		if stage != last {
+			slog.Debug("Google popup stage", "step", "google_login", "stage", stage)
+			last = stage
+		}
+
+		switch stage {
+		case "chooser":
+			var clicked bool
+			if err := s.browser.ExecuteScript(popupCtx, fmt.Sprintf(chooseAccountScript, account), &clicked); err == nil && !clicked {
+				return fmt.Errorf("google account %q is not in the account chooser", s.google.Account)
+			}
+		case "consent":
+			var clicked bool
+			_ = s.browser.ExecuteScript(popupCtx, consentScript, &clicked)
+		case "email":
+			if account == "" {
+				if err := s.waitForHuman(&manualUntil, "Google asks for an email address"); err != nil {
+					return err
+				}
+				break
+			}
+			if err := s.browser.TypeText(popupCtx, `#identifierId, input[type="email"]`, s.google.Account); err != nil && popupCtx.Err() == nil {
+				return fmt.Errorf("type google account: %w", err)
+			}
+			var clicked bool
+			_ = s.browser.ExecuteScript(popupCtx, `(function() {
+				const next = document.querySelector('#identifierNext button, #identifierNext');
+				if (!next) return false;
+				next.click();
+				return true;
+			})()`, &clicked)
+		case "password", "challenge":
+			if err := s.waitForHuman(&manualUntil, "Google asks for a "+stage); err != nil {
+				return err
+			}
+		}
+
+		if manualUntil.IsZero() && time.Now().After(deadline) {
+			return fmt.Errorf("google popup did not complete within %s (stuck at %s)", popupStepTimeout, stage)
+		}
+		if !manualUntil.IsZero() && time.Now().After(manualUntil) {
+			return fmt.Errorf("%w: no one completed the sign-in within %s", ErrManualActionRequired, s.google.ManualTimeout)
+		}
+		if err := s.browser.Sleep(1200, 2200).Do(popupCtx); err != nil {
+			if popupCtx.Err() != nil {
+				slog.Info("Google popup closed", "step", "google_login")
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// waitForHuman switches the popup flow into manual assist, or fails when it
+// is off. The first call starts the manual timeout
+func (s *WallapopAuthService) waitForHuman(until *time.Time, reason string) error {
+	if !s.google.ManualAssist {
+		return fmt.Errorf("%w: %s", ErrManualActionRequired, reason)
+	}
+	if until.IsZero() {
+		*until = time.Now().Add(s.google.ManualTimeout)
+		slog.Warn("Waiting for manual sign-in: complete it in the browser window",
+			"step", "google_login", "reason", reason, "timeout", s.google.ManualTimeout)
+	}
+	return nil
+}
+
+// waitForWallapopRedirect waits until the login tab is back on
+// es.wallapop.com outside the /auth pages
+func (s *wallapopSession) waitForWallapopRedirect(ctx context.Context) error {
+	deadline := time.Now().Add(redirectTimeout)
+	var href string
+	for time.Now().Before(deadline) {
+		if err := s.browser.ExecuteScript(ctx, `location.href`, &href); err == nil {
+			if u, err := url.Parse(href); err == nil && u.Host == "es.wallapop.com" && !strings.HasPrefix(u.Path, "/auth") {
+				slog.Info("Redirected back to Wallapop", "step", "google_login", "url", href)
+				return nil
+			}
+		}
+		if err := s.browser.Sleep(800, 1500).Do(ctx); err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("no redirect back to es.wallapop.com within %s (at %s)", redirectTimeout, href)
+}
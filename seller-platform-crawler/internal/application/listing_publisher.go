@@ -0,0 +1,461 @@
+package application
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"seller-platform-crawler/internal/domain/model"
+	"seller-platform-crawler/internal/domain/ports"
+)
+
+// Wallapop upload limits
+const (
+	maxTitleLength       = 50
+	maxDescriptionLength = 640
+	maxListingPhotos     = 10
+)
+
+const (
+	wallapopUploadURL = "https://es.wallapop.com/app/catalog/upload"
+	wallapopEditURL   = "https://es.wallapop.com/app/catalog/edit/"
+	// wallapopItemsAPI is the endpoint the upload form posts the item to
+	wallapopItemsAPI = "api.wallapop.com/api/v3/items"
+	// publishTimeout bounds one upload, photos included
+	publishTimeout = 3 * time.Minute
+	// listingIDTimeout is how long to wait for the created item after submit
+	listingIDTimeout = 60 * time.Second
+)
+
+// ErrUploadUnconfirmed is returned when the upload form was submitted but no
+// created item came back; the listing may be live, so retrying could
+// duplicate it
+var ErrUploadUnconfirmed = errors.New("upload submitted but not confirmed, the listing may be live")
+
+// errRejected marks an items API response refusing the change
+var errRejected = errors.New("wallapop rejected the listing")
+
+// Selectors of the upload form
+const (
+	uploadTypeSel        = `walla-button[text="Algo que ya no necesito"], [data-testid="upload-type-consumer_goods"]`
+	uploadTitleSel       = `input#summary, input[name="summary"]`
+	uploadCategorySel    = `[data-testid="category-selector"], walla-dropdown[formcontrolname="category_leaf_id"], #category`
+	uploadDescriptionSel = `textarea#description, textarea[name="description"]`
+	uploadPriceSel       = `input#sale_price, input[name="sale_price"]`
+	uploadConditionSel   = `[data-testid="condition-selector"], walla-dropdown[formcontrolname="condition"], #condition`
+	uploadPhotoSel       = `input[type="file"]`
+	uploadPostalCodeSel  = `input#postal_code, input[name="postal_code"], input[name="location"]`
+	uploadSubmitSel      = `walla-button[type="submit"], button[type="submit"]`
+)
+
+// pickOptionScript clicks the open dropdown option whose text is the argument
+const pickOptionScript = `
+	(function(label) {
+		const want = label.trim().toLowerCase();
+		for (const el of document.querySelectorAll('[role="option"], walla-dropdown-option, li')) {
+			if ((el.innerText || '').trim().toLowerCase() === want) {
+				el.click();
+				return true;
+			}
+		}
+		return false;
+	})(%q)
+`
+
+// PublishOptions are the listing fields that do not come from the product
+type PublishOptions struct {
+	// Category is the Wallapop category as shown in the picker; empty picks
+	// it from the product's Obramat category, see SetCategories
+	Category string
+	// Condition is the item condition as shown in the picker
+	Condition string
+	// PostalCode sets the item location; empty keeps the profile location
+	PostalCode string
+	// Price, Title and Description override the values taken from the
+	// product when set
+	Price       float64
+	Title       string
+	Description string
+}
+
+// DefaultPublishOptions lists Obramat stock as new DIY items
+var DefaultPublishOptions = PublishOptions{Category: "Bricolaje", Condition: "Nuevo"}
+
+// listingDraft is what will be typed into the upload form
+type listingDraft struct {
+	Title       string
+	Description string
+	// DescriptionKey identifies the generator inputs of Description
+	DescriptionKey string
+	// DescriptionPending is set on a dry run when the generator would have
+	// written a new description; Description then holds the old one
+	DescriptionPending bool
+	Price              float64
+	// Photos are the downloaded files of PhotoURLs
+	Photos    []string
+	PhotoURLs []string
+}
+
+// ListingPublisher uploads Obramat products to Wallapop
+type ListingPublisher struct {
+	browser      ports.BrowserAutomation
+	products     ports.ProductRepository
+	images       ports.ImageFetcher
+	defaults     PublishOptions
+	listings     ports.MarketplaceListingRepository
+	store        string
+	pricing      PricingRules
+	descriptions ports.DescriptionGenerator
+	categories   CategoryMap
+}
+
+// NewListingPublisher creates a publisher. defaults fill in the options of
+// every upload
+func NewListingPublisher(browser ports.BrowserAutomation, products ports.ProductRepository, images ports.ImageFetcher, defaults PublishOptions) *ListingPublisher {
+	return &ListingPublisher{browser: browser, products: products, images: images, defaults: defaults,
+		categories: NewCategoryMap(defaults.Category)}
+}
+
+// RecordSubmissions makes the publisher store each new listing with the
+// source price and the stock of the given store, so ListingUpdater can tell
+// when the listing falls behind the product
+func (p *ListingPublisher) RecordSubmissions(listings ports.MarketplaceListingRepository, store string) {
+	p.listings = listings
+	p.store = store
+}
+
+// SetPricing makes the publisher derive listing prices from the source
+// price with rules instead of copying it
+func (p *ListingPublisher) SetPricing(rules PricingRules) {
+	p.pricing = rules
+}
+
+// SetCategories makes the publisher pick each product's Wallapop category
+// from its Obramat category; the pricing rules and description templates of
+// that category apply
+func (p *ListingPublisher) SetCategories(m CategoryMap) {
+	p.categories = m
+}
+
+// SetDescriptions makes the publisher write listing descriptions with gen
+// instead of copying the product description
+func (p *ListingPublisher) SetDescriptions(gen ports.DescriptionGenerator) {
+	p.descriptions = gen
+}
+
+// Publish uploads the product with the given id and returns the new listing
+func (p *ListingPublisher) Publish(ctx context.Context, productID int64) (*model.Listing, error) {
+	product, err := p.products.GetProduct(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("product %d: %w", productID, err)
+	}
+	listing, err := p.PublishProduct(ctx, product, PublishOptions{})
+	if rerr := p.browser.ReportResult(err); rerr != nil {
+		return listing, errors.Join(err, fmt.Errorf("restart browser: %w", rerr))
+	}
+	return listing, err
+}
+
+// PublishProduct fills in and submits the upload form for product
+func (p *ListingPublisher) PublishProduct(ctx context.Context, product *model.Product, opts PublishOptions) (*model.Listing, error) {
+	if product.Status != "" && product.Status != model.ProductActive {
+		return nil, fmt.Errorf("product %d is %s, not publishing", product.ID, product.Status)
+	}
+	lg := slog.With("product_id", product.ID, "reference", product.Reference)
+	opts = p.withDefaults(opts, product)
+	draft, err := p.draftFor(ctx, product, opts, nil, false)
+	if err != nil {
+		return nil, err
+	}
+	if draft.Title == "" {
+		return nil, fmt.Errorf("product %d has no title", product.ID)
+	}
+	if draft.Price <= 0 {
+		return nil, fmt.Errorf("product %d has no price", product.ID)
+	}
+
+	photoDir, err := os.MkdirTemp("", "wallapop-photos-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(photoDir)
+	draft.Photos, err = p.images.Download(ctx, draft.PhotoURLs, photoDir)
+	if err != nil {
+		return nil, fmt.Errorf("download photos: %w", err)
+	}
+	if len(draft.Photos) == 0 {
+		return nil, fmt.Errorf("product %d: no photo could be downloaded", product.ID)
+	}
+
+	tabCtx, cancel := p.browser.CreateTabContext(publishTimeout)
+	defer cancel()
+	responses := p.browser.WatchResponses(tabCtx, wallapopItemsAPI)
+
+	if err := p.fillUploadForm(tabCtx, draft, opts); err != nil {
+		return nil, err
+	}
+	lg.Info("Submitting listing", "step", "publish", "title", draft.Title, "price", draft.Price, "photos", len(draft.Photos))
+	if err := p.browser.ClickElement(tabCtx, uploadSubmitSel); err != nil {
+		return nil, fmt.Errorf("submit upload form: %w", err)
+	}
+
+	listing, err := p.awaitListing(tabCtx, responses)
+	if err != nil {
+		if !errors.Is(err, errRejected) {
+			err = fmt.Errorf("%w: %w", ErrUploadUnconfirmed, err)
+		}
+		return nil, err
+	}
+	lg.Info("Listing published", "step", "publish", "listing_id", listing.ID, "url", listing.URL)
+	if p.listings != nil {
+		sub := model.ListingSubmission{
+			ListingID:      listing.ID,
+			URL:            listing.URL,
+			ProductID:      product.ID,
+			Title:          draft.Title,
+			Description:    draft.Description,
+			DescriptionKey: draft.DescriptionKey,
+			Price:          draft.Price,
+			Photos:         draft.PhotoURLs,
+			SourcePrice:    product.Price,
+		}
+		if sub.SourceStock, err = p.products.StoreStock(ctx, product.ID, p.store); err != nil {
+			lg.Warn("Store stock unavailable", "step", "publish", "err", err)
+		}
+		if err := p.listings.RecordSubmission(ctx, sub); err != nil {
+			lg.Warn("Failed to record listing submission", "step", "publish", "listing_id", listing.ID, "err", err)
+		}
+	}
+	return listing, nil
+}
+
+// withDefaults fills the empty fields of opts from the publisher defaults
+// and the product's category
+func (p *ListingPublisher) withDefaults(opts PublishOptions, product *model.Product) PublishOptions {
+	if opts.Category == "" {
+		opts.Category = p.categories.For(product)
+	}
+	if opts.Condition == "" {
+		opts.Condition = p.defaults.Condition
+	}
+	if opts.PostalCode == "" {
+		opts.PostalCode = p.defaults.PostalCode
+	}
+	return opts
+}
+
+// draftFor builds the form values from product and the overrides in opts;
+// the price goes through the pricing rules and the description, unless
+// given, through the description generator. A generated description of prev
+// is reused while its inputs are unchanged, and with dryRun the generator is
+// never called
+func (p *ListingPublisher) draftFor(ctx context.Context, product *model.Product, opts PublishOptions, prev *model.ListingSubmission, dryRun bool) (listingDraft, error) {
+	d := listingDraft{
+		Title:       product.Title,
+		Description: product.Description,
+		Price:       p.pricing.Price(product.Price, opts.Category),
+		PhotoURLs:   product.Images,
+	}
+	if len(d.PhotoURLs) > maxListingPhotos {
+		d.PhotoURLs = d.PhotoURLs[:maxListingPhotos]
+	}
+	if opts.Title != "" {
+		d.Title = opts.Title
+	}
+	if opts.Description != "" {
+		d.Description = opts.Description
+	}
+	if opts.Price > 0 {
+		d.Price = opts.Price
+	}
+	if opts.Description == "" && p.descriptions != nil {
+		req := ports.DescriptionRequest{
+			Product:   product,
+			Category:  opts.Category,
+			Condition: opts.Condition,
+			Price:     d.Price,
+			MaxLength: maxDescriptionLength,
+		}
+		d.DescriptionKey = p.descriptionKey(req)
+		switch {
+		case prev != nil && prev.DescriptionKey == d.DescriptionKey && prev.Description != "":
+			d.Description = prev.Description
+		case dryRun:
+			d.DescriptionPending = true
+			if prev != nil {
+				d.Description = prev.Description
+			}
+		default:
+			text, err := p.descriptions.Generate(ctx, req)
+			if err != nil {
+				return d, fmt.Errorf("product %d description: %w", product.ID, err)
+			}
+			d.Description = text
+		}
+	}
+	d.Title = truncateText(strings.Join(strings.Fields(d.Title), " "), maxTitleLength)
+	d.Description = truncateText(strings.TrimSpace(d.Description), maxDescriptionLength)
+	return d, nil
+}
+
+// descriptionKey hashes what the description generator works from, so a
+// description is only rewritten when one of its inputs changed
+func (p *ListingPublisher) descriptionKey(req ports.DescriptionRequest) string {
+	h := sha256.New()
+	pr := req.Product
+	fmt.Fprintf(h, "%T\x00%s\x00%s\x00%s\x00%s\x00%s\x00%s\x00%s\x00%.2f\x00%d",
+		p.descriptions, pr.Title, pr.Brand, pr.Model, pr.Reference, pr.Description,
+		req.Category, req.Condition, req.Price, req.MaxLength)
+	for _, s := range pr.Specs {
+		fmt.Fprintf(h, "\x00%s=%s", s.Name, s.Value)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// fillUploadForm opens the upload page and types in the draft
+func (p *ListingPublisher) fillUploadForm(ctx context.Context, d listingDraft, opts PublishOptions) error {
+	if err := p.browser.Navigate(ctx, wallapopUploadURL); err != nil {
+		return fmt.Errorf("navigate failed (%s): %w", wallapopUploadURL, err)
+	}
+	if err := p.browser.WaitForElement(ctx, uploadTypeSel); err != nil {
+		if url, urlErr := p.browser.CurrentURL(ctx); urlErr == nil && strings.Contains(url, "/auth") {
+			return fmt.Errorf("%w: upload page redirected to %s", ports.ErrSessionExpired, url)
+		}
+		return fmt.Errorf("upload type selector not found: %w", err)
+	}
+	if err := p.browser.ClickElement(ctx, uploadTypeSel); err != nil {
+		return fmt.Errorf("choose upload type: %w", err)
+	}
+
+	steps := []struct {
+		name string
+		do   func() error
+	}{
+		{"title", func() error { return p.browser.TypeText(ctx, uploadTitleSel, d.Title) }},
+		{"category", func() error { return p.pickOption(ctx, uploadCategorySel, opts.Category) }},
+		{"photos", func() error { return p.browser.SetUploadFiles(ctx, uploadPhotoSel, d.Photos) }},
+		{"description", func() error { return p.browser.TypeText(ctx, uploadDescriptionSel, d.Description) }},
+		{"price", func() error { return p.browser.TypeText(ctx, uploadPriceSel, formatPrice(d.Price)) }},
+		{"condition", func() error { return p.pickOption(ctx, uploadConditionSel, opts.Condition) }},
+		{"location", func() error {
+			if opts.PostalCode == "" {
+				return nil
+			}
+			return p.browser.TypeText(ctx, uploadPostalCodeSel, opts.PostalCode)
+		}},
+	}
+	for _, step := range steps {
+		if err := p.browser.Pause(ctx, 600, 1400); err != nil {
+			return err
+		}
+		if err := step.do(); err != nil {
+			return fmt.Errorf("upload form %s: %w", step.name, err)
+		}
+		slog.Debug("Upload form field set", "step", "publish", "field", step.name)
+	}
+	return p.browser.Pause(ctx, 1500, 3000)
+}
+
+// pickOption opens a dropdown and clicks the option with the given text
+func (p *ListingPublisher) pickOption(ctx context.Context, dropdownSel, label string) error {
+	if err := p.browser.ClickElement(ctx, dropdownSel); err != nil {
+		return err
+	}
+	if err := p.browser.Pause(ctx, 500, 1000); err != nil {
+		return err
+	}
+	var picked bool
+	if err := p.browser.ExecuteScript(ctx, fmt.Sprintf(pickOptionScript, label), &picked); err != nil {
+		return err
+	}
+	if !picked {
+		return fmt.Errorf("option %q not found", label)
+	}
+	return nil
+}
+
+// awaitListing waits for the item the upload form created. The id comes from
+// the items API response; the URL is the public item page when Wallapop
+// redirects to it, else the edit page of the item
+func (p *ListingPublisher) awaitListing(ctx context.Context, responses <-chan ports.NetworkResponse) (*model.Listing, error) {
+	body, err := awaitItemSaved(ctx, responses, http.MethodPost, "")
+	if err != nil {
+		return nil, err
+	}
+	var item struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(body, &item); err != nil || item.ID == "" {
+		return nil, fmt.Errorf("no item id in upload response %q", truncateText(string(body), 300))
+	}
+	listing := &model.Listing{ID: item.ID, URL: wallapopEditURL + item.ID}
+	if err := p.browser.Pause(ctx, 2000, 4000); err == nil {
+		if url, err := p.browser.CurrentURL(ctx); err == nil && strings.Contains(url, "/item/") {
+			listing.URL = url
+		}
+	}
+	return listing, nil
+}
+
+// awaitItemSaved waits for the items API response to the given method, or
+// to any method but GET when method is empty, and returns its body, or an
+// error when Wallapop rejected the change. A non-empty itemID only accepts
+// responses about that item
+func awaitItemSaved(ctx context.Context, responses <-chan ports.NetworkResponse, method, itemID string) ([]byte, error) {
+	timeout := time.NewTimer(listingIDTimeout)
+	defer timeout.Stop()
+	for {
+		select {
+		case r := <-responses:
+			if method != "" && r.Method != method || method == "" && (r.Method == http.MethodGet || r.Method == http.MethodOptions) {
+				continue
+			}
+			if itemID != "" && !strings.Contains(r.URL, "items/"+itemID) {
+				continue
+			}
+			if r.Status >= 300 {
+				return nil, fmt.Errorf("%w: HTTP %d: %s", errRejected, r.Status, truncateText(string(r.Body), 300))
+			}
+			return r.Body, nil
+		case <-timeout.C:
+			what := method
+			if what == "" {
+				what = "write"
+			}
+			return nil, fmt.Errorf("no %s response from the items API within %s", what, listingIDTimeout)
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// formatPrice writes a price the way the form expects it, e.g. 12,5
+func formatPrice(price float64) string {
+	return strings.Replace(strconv.FormatFloat(price, 'f', -1, 64), ".", ",", 1)
+}
+
+// truncateText cuts s to max runes, at a word boundary when there is one in
+// the last quarter, and marks the cut with an ellipsis
+func truncateText(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	cut := r[:max-1]
+	for i := len(cut) - 1; i >= len(cut)*3/4; i-- {
+		if cut[i] == ' ' || cut[i] == '\n' {
+			cut = cut[:i]
+			break
+		}
+	}
+	return strings.TrimRight(string(cut), " \n.,;:") + "…"
+}
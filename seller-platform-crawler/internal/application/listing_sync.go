@@ -0,0 +1,256 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"seller-platform-crawler/internal/domain/model"
+	"seller-platform-crawler/internal/domain/ports"
+)
+
+const (
+	wallapopItemURL = "https://es.wallapop.com/item/"
+	// wallapopAPI prefixes the API calls the catalog pages make
+	wallapopAPI = "api.wallapop.com/api/v3/"
+	// syncTimeout bounds the sync of one catalog page
+	syncTimeout = 5 * time.Minute
+	// maxCatalogScrolls bounds the infinite scroll of a catalog page
+	maxCatalogScrolls = 60
+	// idleScrolls is how many scrolls without new items end a page
+	idleScrolls = 3
+)
+
+// catalogPages are the pages of our own items and the status they imply.
+// An empty page that must list items means the page failed to load: a
+// reseller always has published items, but may have sold none yet
+var catalogPages = []struct {
+	url      string
+	status   string
+	nonEmpty bool
+}{
+	{"https://es.wallapop.com/app/catalog/published", model.ListingPublished, true},
+	{"https://es.wallapop.com/app/catalog/sold", model.ListingSold, false},
+}
+
+// apiItem is an item as returned by the API behind the catalog pages. The
+// fields vary between endpoints, so the alternatives are all decoded
+type apiItem struct {
+	ID        string          `json:"id"`
+	Title     string          `json:"title"`
+	WebSlug   string          `json:"web_slug"`
+	Price     json.RawMessage `json:"price"`
+	SalePrice float64         `json:"sale_price"`
+	Currency  string          `json:"currency"`
+	Views     int             `json:"views"`
+	Visits    int             `json:"visits"`
+	Favorites int             `json:"favorites"`
+	Counters  struct {
+		Views     int `json:"views"`
+		Favorites int `json:"favorites"`
+	} `json:"counters"`
+	Flags struct {
+		Reserved bool `json:"reserved"`
+		Sold     bool `json:"sold"`
+	} `json:"flags"`
+	Reserved struct {
+		Flag bool `json:"flag"`
+	} `json:"reserved"`
+}
+
+// SyncResult counts the outcome of a listing sync
+type SyncResult struct {
+	Listings int
+	Linked   int
+	Removed  int
+}
+
+// ListingSync reads our items from the Wallapop catalog pages into the
+// marketplace listings repository
+type ListingSync struct {
+	browser  ports.BrowserAutomation
+	listings ports.MarketplaceListingRepository
+}
+
+// NewListingSync creates a listing sync
+func NewListingSync(browser ports.BrowserAutomation, listings ports.MarketplaceListingRepository) *ListingSync {
+	return &ListingSync{browser: browser, listings: listings}
+}
+
+// Sync stores every listing of the logged-in account. Listings stored by an
+// earlier sync that no catalog page shows any more are marked removed, but
+// only when every page was read to the end and the published page showed at
+// least one item
+func (s *ListingSync) Sync(ctx context.Context) (SyncResult, error) {
+	var res SyncResult
+	syncedAt := time.Now()
+	complete := true
+	for _, page := range catalogPages {
+		items, pageComplete, err := s.readCatalog(ctx, page.url, page.status)
+		if rerr := s.browser.ReportResult(err); rerr != nil {
+			return res, errors.Join(err, fmt.Errorf("restart browser: %w", rerr))
+		}
+		if err != nil {
+			return res, fmt.Errorf("catalog %s: %w", page.url, err)
+		}
+		if !pageComplete || (page.nonEmpty && len(items) == 0) {
+			complete = false
+		}
+		for _, l := range items {
+			productID, err := s.listings.Upsert(ctx, l, syncedAt)
+			if err != nil {
+				return res, fmt.Errorf("store listing %s: %w", l.ListingID, err)
+			}
+			res.Listings++
+			if productID != 0 {
+				res.Linked++
+			}
+		}
+	}
+	if !complete {
+		slog.Warn("A catalog page was not read completely or showed no published items, not marking missing listings removed", "step", "listing_sync")
+	} else {
+		n, err := s.listings.MarkMissing(ctx, syncedAt)
+		if err != nil {
+			return res, err
+		}
+		res.Removed = n
+	}
+	slog.Info("Listing sync finished", "step", "listing_sync", "listings", res.Listings, "linked", res.Linked, "removed", res.Removed)
+	return res, nil
+}
+
+// readCatalog loads a catalog page and scrolls it to the end, collecting the
+// items of every API response it triggers. complete is false when the scroll
+// limit was hit or an API response could not be read
+func (s *ListingSync) readCatalog(ctx context.Context, pageURL, status string) (items []model.MarketplaceListing, complete bool, err error) {
+	tabCtx, cancel := s.browser.CreateTabContext(syncTimeout)
+	defer cancel()
+	responses := s.browser.WatchResponses(tabCtx, wallapopAPI)
+
+	if err := s.browser.Navigate(tabCtx, pageURL); err != nil {
+		return nil, false, fmt.Errorf("navigate failed (%s): %w", pageURL, err)
+	}
+	if url, err := s.browser.CurrentURL(tabCtx); err == nil && strings.Contains(url, "/auth") {
+		return nil, false, fmt.Errorf("%w: catalog redirected to %s", ports.ErrSessionExpired, url)
+	}
+
+	seen := map[string]model.MarketplaceListing{}
+	var order []string
+	idle := 0
+	unreadable := 0
+	for scroll := 0; scroll < maxCatalogScrolls && idle < idleScrolls; scroll++ {
+		if err := s.browser.Pause(tabCtx, 1500, 3000); err != nil {
+			return nil, false, err
+		}
+		added := 0
+	drain:
+		for {
+			select {
+			case r := <-responses:
+				if r.Method == http.MethodGet && (r.Status >= 400 || r.Body == nil) {
+					unreadable++
+				}
+				for _, l := range parseCatalogItems(r.Body, status) {
+					if _, ok := seen[l.ListingID]; !ok {
+						order = append(order, l.ListingID)
+						added++
+					}
+					seen[l.ListingID] = l
+				}
+			default:
+				break drain
+			}
+		}
+		if added == 0 {
+			idle++
+		} else {
+			idle = 0
+		}
+		var ignored bool
+		if err := s.browser.ExecuteScript(tabCtx, `window.scrollTo(0, document.body.scrollHeight) || true`, &ignored); err != nil {
+			return nil, false, fmt.Errorf("scroll: %w", err)
+		}
+	}
+
+	items = make([]model.MarketplaceListing, 0, len(order))
+	for _, id := range order {
+		items = append(items, seen[id])
+	}
+	complete = idle >= idleScrolls && unreadable == 0
+	slog.Info("Catalog read", "step", "listing_sync", "page", pageURL, "items", len(items),
+		"complete", complete, "unreadable_responses", unreadable)
+	return items, complete, nil
+}
+
+// parseCatalogItems decodes an API response that lists items, either as a
+// bare array or under "data" or "items". Other responses yield nothing
+func parseCatalogItems(body []byte, status string) []model.MarketplaceListing {
+	var raw []apiItem
+	var wrapped struct {
+		Data  []apiItem `json:"data"`
+		Items []apiItem `json:"items"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		if err := json.Unmarshal(body, &wrapped); err != nil {
+			return nil
+		}
+		raw = append(wrapped.Data, wrapped.Items...)
+	}
+
+	var out []model.MarketplaceListing
+	for _, it := range raw {
+		if it.ID == "" || it.Title == "" {
+			continue
+		}
+		l := model.MarketplaceListing{
+			ListingID:  it.ID,
+			Title:      it.Title,
+			Currency:   "EUR",
+			Views:      max(it.Views, it.Visits, it.Counters.Views),
+			Favourites: max(it.Favorites, it.Counters.Favorites),
+			Status:     status,
+		}
+		l.Price, l.Currency = it.price()
+		if it.WebSlug != "" {
+			l.URL = wallapopItemURL + it.WebSlug
+		}
+		switch {
+		case it.Flags.Sold:
+			l.Status = model.ListingSold
+		case it.Flags.Reserved || it.Reserved.Flag:
+			l.Status = model.ListingReserved
+		}
+		out = append(out, l)
+	}
+	return out
+}
+
+// price reads "price" as a number or as {"amount", "currency"}, falling back
+// to sale_price
+func (it apiItem) price() (float64, string) {
+	currency := it.Currency
+	if currency == "" {
+		currency = "EUR"
+	}
+	var amount float64
+	if json.Unmarshal(it.Price, &amount) == nil && amount > 0 {
+		return amount, currency
+	}
+	var obj struct {
+		Amount   float64 `json:"amount"`
+		Currency string  `json:"currency"`
+	}
+	if json.Unmarshal(it.Price, &obj) == nil && obj.Amount > 0 {
+		if obj.Currency != "" {
+			currency = obj.Currency
+		}
+		return obj.Amount, currency
+	}
+	return it.SalePrice, currency
+}
@@ -0,0 +1,312 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"seller-platform-crawler/internal/domain/model"
+	"seller-platform-crawler/internal/domain/ports"
+)
+
+// removePhotosScript clicks the delete button of every photo in the edit
+// form's photo area, never the page's other Eliminar buttons such as the one
+// deleting the listing. It returns -1 when the photo area is missing
+const removePhotosScript = `
+	(function() {
+		const area = document.querySelector('[data-testid="drop-area"], tsl-drop-area, .DropArea');
+		if (!area) return -1;
+		const buttons = area.querySelectorAll('[data-testid="remove-image"], button[aria-label*="Eliminar"], .DropArea__remove');
+		buttons.forEach(b => b.click());
+		return buttons.length;
+	})()
+`
+
+// UpdateThresholds decide when a listing has fallen behind its product
+type UpdateThresholds struct {
+	// PriceChange is the relative change of the source price, e.g. 0.03
+	PriceChange float64
+	// MinPriceChange is the smallest absolute change in euros worth an edit
+	MinPriceChange float64
+	// StockChange is the change in store units
+	StockChange int
+}
+
+// DefaultUpdateThresholds ignore rounding noise and small stock movements
+var DefaultUpdateThresholds = UpdateThresholds{PriceChange: 0.03, MinPriceChange: 1, StockChange: 5}
+
+// fieldChange is one line of a listing diff
+type fieldChange struct {
+	Field string
+	From  string
+	To    string
+}
+
+func (c fieldChange) String() string {
+	return fmt.Sprintf("%s: %s -> %s", c.Field, c.From, c.To)
+}
+
+// UpdateResult counts the outcome of an update run
+type UpdateResult struct {
+	Checked   int
+	Triggered int
+	Updated   int
+	Failed    int
+}
+
+// ListingUpdater edits live listings whose source product changed
+type ListingUpdater struct {
+	browser    ports.BrowserAutomation
+	products   ports.ProductRepository
+	listings   ports.MarketplaceListingRepository
+	publisher  *ListingPublisher
+	thresholds UpdateThresholds
+	store      string
+}
+
+// NewListingUpdater creates an updater. The publisher builds the new listing
+// values the same way it does for an upload; store names the store whose
+// stock is tracked
+func NewListingUpdater(browser ports.BrowserAutomation, products ports.ProductRepository, listings ports.MarketplaceListingRepository,
+	publisher *ListingPublisher, thresholds UpdateThresholds, store string) *ListingUpdater {
+	return &ListingUpdater{
+		browser:    browser,
+		products:   products,
+		listings:   listings,
+		publisher:  publisher,
+		thresholds: thresholds,
+		store:      store,
+	}
+}
+
+// Run checks every linked listing and edits those whose product price or
+// stock moved beyond the thresholds. With dryRun the diffs are only logged
+func (u *ListingUpdater) Run(ctx context.Context, dryRun bool) (UpdateResult, error) {
+	var res UpdateResult
+	linked, err := u.listings.Linked(ctx)
+	if err != nil {
+		return res, err
+	}
+	for _, l := range linked {
+		if l.Status == model.ListingRemoved {
+			continue
+		}
+		res.Checked++
+		lg := slog.With("step", "listing_update", "listing_id", l.ListingID, "product_id", l.ProductID)
+		product, err := u.products.GetProduct(ctx, l.ProductID)
+		if err != nil {
+			lg.Warn("Source product unavailable", "err", err)
+			res.Failed++
+			continue
+		}
+		stock, err := u.products.StoreStock(ctx, l.ProductID, u.store)
+		if err != nil {
+			return res, err
+		}
+		reasons := u.thresholds.reasons(l, product.Price, stock)
+		if len(reasons) == 0 {
+			continue
+		}
+		res.Triggered++
+
+		draft, err := u.publisher.draftFor(ctx, product, u.publisher.withDefaults(PublishOptions{}, product), l.Submission, dryRun)
+		if err != nil {
+			lg.Warn("Listing draft failed", "err", err)
+			res.Failed++
+			continue
+		}
+		changes := listingChanges(l, draft)
+		sub := model.ListingSubmission{
+			ListingID:      l.ListingID,
+			ProductID:      l.ProductID,
+			Title:          draft.Title,
+			Description:    draft.Description,
+			DescriptionKey: draft.DescriptionKey,
+			Price:          draft.Price,
+			Photos:         draft.PhotoURLs,
+			SourcePrice:    product.Price,
+			SourceStock:    stock,
+		}
+		if len(changes) == 0 {
+			lg.Info("Source changed but the listing is up to date", "reasons", strings.Join(reasons, "; "))
+			if !dryRun {
+				if err := u.listings.RecordSubmission(ctx, sub); err != nil {
+					return res, err
+				}
+			}
+			continue
+		}
+		lines := make([]string, len(changes))
+		for i, c := range changes {
+			lines[i] = c.String()
+		}
+		lg.Info("Listing update", "dry_run", dryRun, "reasons", strings.Join(reasons, "; "), "diff", strings.Join(lines, "; "))
+		if dryRun {
+			continue
+		}
+
+		err = u.UpdateListing(ctx, l, draft, changes)
+		if rerr := u.browser.ReportResult(err); rerr != nil {
+			return res, errors.Join(err, fmt.Errorf("restart browser: %w", rerr))
+		}
+		if err != nil {
+			res.Failed++
+			lg.Warn("Listing update failed", "err", err)
+			if errors.Is(err, ports.ErrSessionExpired) {
+				return res, err
+			}
+			continue
+		}
+		if err := u.listings.RecordSubmission(ctx, sub); err != nil {
+			return res, err
+		}
+		res.Updated++
+		if err := u.browser.Pause(ctx, 20000, 45000); err != nil {
+			return res, err
+		}
+	}
+	slog.Info("Listing update finished", "step", "listing_update", "dry_run", dryRun,
+		"checked", res.Checked, "triggered", res.Triggered, "updated", res.Updated, "failed", res.Failed)
+	return res, nil
+}
+
+// reasons lists the thresholds the product crossed since the last
+// submission. Without one, the live listing price is the baseline
+func (t UpdateThresholds) reasons(l model.LinkedListing, price float64, stock *int) []string {
+	var out []string
+	base := l.Price
+	if l.Submission != nil && l.Submission.SourcePrice > 0 {
+		base = l.Submission.SourcePrice
+	}
+	if base > 0 && price > 0 {
+		delta := price - base
+		if math.Abs(delta) >= t.MinPriceChange && math.Abs(delta)/base >= t.PriceChange {
+			out = append(out, fmt.Sprintf("source price %.2f -> %.2f (%+.1f%%)", base, price, 100*delta/base))
+		}
+	}
+	if l.Submission != nil && l.Submission.SourceStock != nil && stock != nil {
+		was, now := *l.Submission.SourceStock, *stock
+		if d := now - was; d >= t.StockChange || -d >= t.StockChange {
+			out = append(out, fmt.Sprintf("store stock %d -> %d", was, now))
+		}
+	}
+	return out
+}
+
+// listingChanges compares the draft with what the listing shows or what we
+// last submitted
+func listingChanges(l model.LinkedListing, d listingDraft) []fieldChange {
+	var changes []fieldChange
+	if math.Abs(l.Price-d.Price) >= 0.01 {
+		changes = append(changes, fieldChange{"price", fmt.Sprintf("%.2f", l.Price), fmt.Sprintf("%.2f", d.Price)})
+	}
+	if d.DescriptionPending {
+		changes = append(changes, fieldChange{"description", fmt.Sprintf("%d chars", len([]rune(d.Description))), "regenerated"})
+	}
+	if l.Submission == nil {
+		if !d.DescriptionPending {
+			changes = append(changes, fieldChange{"description", "unknown", fmt.Sprintf("%d chars", len([]rune(d.Description)))})
+		}
+		return changes
+	}
+	if !d.DescriptionPending && l.Submission.Description != d.Description {
+		changes = append(changes, fieldChange{"description",
+			fmt.Sprintf("%d chars", len([]rune(l.Submission.Description))),
+			fmt.Sprintf("%d chars", len([]rune(d.Description)))})
+	}
+	if !slices.Equal(l.Submission.Photos, d.PhotoURLs) {
+		changes = append(changes, fieldChange{"photos", fmt.Sprintf("%d", len(l.Submission.Photos)), fmt.Sprintf("%d", len(d.PhotoURLs))})
+	}
+	return changes
+}
+
+// UpdateListing opens the edit form of a listing, changes the fields named
+// in changes and saves it
+func (u *ListingUpdater) UpdateListing(ctx context.Context, l model.LinkedListing, d listingDraft, changes []fieldChange) error {
+	listingID := l.ListingID
+	changed := map[string]bool{}
+	for _, c := range changes {
+		changed[c.Field] = true
+	}
+	if changed["photos"] {
+		dir, err := os.MkdirTemp("", "wallapop-photos-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		if d.Photos, err = u.publisher.images.Download(ctx, d.PhotoURLs, dir); err != nil {
+			return fmt.Errorf("download photos: %w", err)
+		}
+		if len(d.Photos) == 0 {
+			return errors.New("no photo could be downloaded")
+		}
+	}
+
+	tabCtx, cancel := u.browser.CreateTabContext(publishTimeout)
+	defer cancel()
+	responses := u.browser.WatchResponses(tabCtx, wallapopItemsAPI)
+
+	editURL := wallapopEditURL + listingID
+	if err := u.browser.Navigate(tabCtx, editURL); err != nil {
+		return fmt.Errorf("navigate failed (%s): %w", editURL, err)
+	}
+	if err := u.browser.WaitForElement(tabCtx, uploadTitleSel); err != nil {
+		if url, urlErr := u.browser.CurrentURL(tabCtx); urlErr == nil && strings.Contains(url, "/auth") {
+			return fmt.Errorf("%w: edit page redirected to %s", ports.ErrSessionExpired, url)
+		}
+		return fmt.Errorf("edit form not found: %w", err)
+	}
+
+	replace := func(sel, text string) error {
+		if err := u.browser.ClearInput(tabCtx, sel); err != nil {
+			return err
+		}
+		return u.browser.TypeText(tabCtx, sel, text)
+	}
+	if changed["price"] {
+		if err := replace(uploadPriceSel, formatPrice(d.Price)); err != nil {
+			return fmt.Errorf("edit price: %w", err)
+		}
+	}
+	if changed["description"] {
+		if err := u.browser.Pause(tabCtx, 600, 1400); err != nil {
+			return err
+		}
+		if err := replace(uploadDescriptionSel, d.Description); err != nil {
+			return fmt.Errorf("edit description: %w", err)
+		}
+	}
+	if changed["photos"] {
+		var removed int
+		if err := u.browser.ExecuteScript(tabCtx, removePhotosScript, &removed); err != nil {
+			return fmt.Errorf("remove photos: %w", err)
+		}
+		if removed != len(l.Submission.Photos) {
+			// the form was left unsaved, the listing is unchanged
+			return fmt.Errorf("remove photos: %d remove buttons for the %d submitted photos", removed, len(l.Submission.Photos))
+		}
+		if err := u.browser.Pause(tabCtx, 800, 1600); err != nil {
+			return err
+		}
+		if err := u.browser.SetUploadFiles(tabCtx, uploadPhotoSel, d.Photos); err != nil {
+			return fmt.Errorf("upload photos: %w", err)
+		}
+	}
+	if err := u.browser.Pause(tabCtx, 1500, 3000); err != nil {
+		return err
+	}
+	if err := u.browser.ClickElement(tabCtx, uploadSubmitSel); err != nil {
+		return fmt.Errorf("submit edit form: %w", err)
+	}
+	if _, err := awaitItemSaved(tabCtx, responses, http.MethodPut, listingID); err != nil {
+		return err
+	}
+	slog.Info("Listing updated", "step", "listing_update", "listing_id", listingID)
+	return nil
+}
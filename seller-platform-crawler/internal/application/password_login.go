@@ -0,0 +1,101 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"seller-platform-crawler/internal/domain/ports"
+	"seller-platform-crawler/internal/infrastructure/browser"
+)
+
+// Selectors of the email login form on the onboarding page
+const (
+	emailLoginBtnSel = `walla-button[text="Continuar con email"], walla-button[text="Inicia sesión con tu email"]`
+	emailInputSel    = `input[name="emailAddress"], input[type="email"]`
+	passwordInputSel = `input[name="password"], input[type="password"]`
+	loginSubmitSel   = `walla-button[type="submit"], button[type="submit"]`
+	// loginErrorScript returns the text of the form error, if any
+	loginErrorScript = `
+		(function() {
+			const el = document.querySelector('walla-text-input[error-message], [role="alert"], .error-message');
+			if (!el) return '';
+			return (el.getAttribute('error-message') || el.innerText || '').trim();
+		})()
+	`
+)
+
+// PasswordAuthService logs in with an email and password, for accounts that
+// are not linked to Google
+type PasswordAuthService struct {
+	wallapopSession
+	creds ports.Credentials
+}
+
+var _ ports.AuthService = (*PasswordAuthService)(nil)
+
+// NewPasswordAuthService creates an email and password login. Both fields of
+// creds are required
+func NewPasswordAuthService(browserAdapter *browser.ChromeDPAdapter, creds ports.Credentials) (*PasswordAuthService, error) {
+	if creds.Email == "" || creds.Password == "" {
+		return nil, errors.New("password login needs an email and a password")
+	}
+	return &PasswordAuthService{
+		wallapopSession: wallapopSession{browser: browserAdapter},
+		creds:           creds,
+	}, nil
+}
+
+// Login fills in the email login form unless a valid session already exists
+func (s *PasswordAuthService) Login(ctx context.Context) error {
+	if err := s.ensureBrowser(); err != nil {
+		return err
+	}
+	tabCtx, cancel := s.browser.CreateTabContext(45*time.Second + redirectTimeout)
+	defer cancel()
+
+	if valid, err := s.resumeSession(tabCtx); valid || err != nil {
+		return err
+	}
+	if err := s.openLoginPage(tabCtx); err != nil {
+		return err
+	}
+
+	if err := s.browser.WaitForElement(tabCtx, emailLoginBtnSel); err != nil {
+		return fmt.Errorf("email login button not found: %w", err)
+	}
+	if err := s.browser.ClickElement(tabCtx, emailLoginBtnSel); err != nil {
+		return fmt.Errorf("failed to click email login: %w", err)
+	}
+	if err := s.browser.Sleep(800, 1600).Do(tabCtx); err != nil {
+		return err
+	}
+
+	if err := s.browser.TypeText(tabCtx, emailInputSel, s.creds.Email); err != nil {
+		return fmt.Errorf("type email: %w", err)
+	}
+	if err := s.browser.Sleep(400, 900).Do(tabCtx); err != nil {
+		return err
+	}
+	if err := s.browser.TypeText(tabCtx, passwordInputSel, s.creds.Password); err != nil {
+		return fmt.Errorf("type password: %w", err)
+	}
+	if err := s.browser.Sleep(500, 1200).Do(tabCtx); err != nil {
+		return err
+	}
+	if err := s.browser.ClickElement(tabCtx, loginSubmitSel); err != nil {
+		return fmt.Errorf("submit login form: %w", err)
+	}
+	slog.Info("Email login form submitted", "step", "password_login")
+
+	if err := s.finishLogin(tabCtx); err != nil {
+		var formErr string
+		if s.browser.ExecuteScript(tabCtx, loginErrorScript, &formErr) == nil && formErr != "" {
+			return fmt.Errorf("%w (form says: %s)", err, formErr)
+		}
+		return err
+	}
+	return nil
+}
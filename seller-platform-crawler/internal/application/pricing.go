@@ -0,0 +1,190 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"strings"
+
+	"seller-platform-crawler/internal/domain/ports"
+)
+
+// Rounding modes of a pricing rule
+const (
+	RoundNone = "none"
+	// Round99 rounds up to the next price ending in ,99
+	Round99 = ".99"
+	// Round00 rounds up to whole euros
+	Round00 = ".00"
+)
+
+// PricingRule turns a source price into a listing price. Unset fields of a
+// category rule fall back to the base rule
+type PricingRule struct {
+	// MarkupPercent is added to the source price, e.g. 25 for +25%
+	MarkupPercent *float64 `json:"markup_percent"`
+	// FixedMargin is added after the markup, in euros
+	FixedMargin *float64 `json:"fixed_margin"`
+	// Shipping is added to every price, to cover the shipping cost of
+	// listings sold with free shipping
+	Shipping *float64 `json:"shipping"`
+	// Rounding is none, .99 or .00
+	Rounding string `json:"rounding"`
+	// MinPrice and MaxPrice cap the result
+	MinPrice *float64 `json:"min_price"`
+	MaxPrice *float64 `json:"max_price"`
+}
+
+// PricingRules is the base rule plus overrides keyed by Wallapop category
+type PricingRules struct {
+	PricingRule
+	Categories map[string]PricingRule `json:"categories"`
+}
+
+// LoadPricingRules reads a pricing rules JSON file
+func LoadPricingRules(path string) (PricingRules, error) {
+	var rules PricingRules
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return rules, err
+	}
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return rules, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if err := rules.PricingRule.validate(); err != nil {
+		return rules, fmt.Errorf("%s: %w", path, err)
+	}
+	for name, r := range rules.Categories {
+		if err := r.validate(); err != nil {
+			return rules, fmt.Errorf("%s: category %q: %w", path, name, err)
+		}
+	}
+	return rules, nil
+}
+
+func (r PricingRule) validate() error {
+	switch r.Rounding {
+	case "", RoundNone, Round99, Round00:
+	default:
+		return fmt.Errorf("rounding %q, want %s, %s or %s", r.Rounding, RoundNone, Round99, Round00)
+	}
+	if r.MinPrice != nil && r.MaxPrice != nil && *r.MinPrice > *r.MaxPrice {
+		return fmt.Errorf("min_price %.2f above max_price %.2f", *r.MinPrice, *r.MaxPrice)
+	}
+	return nil
+}
+
+// For returns the rule of a category, the base rule with the category's
+// fields laid over it. Category names match case-insensitively
+func (rs PricingRules) For(category string) PricingRule {
+	rule := rs.PricingRule
+	for name, o := range rs.Categories {
+		if !strings.EqualFold(strings.TrimSpace(name), strings.TrimSpace(category)) {
+			continue
+		}
+		if o.MarkupPercent != nil {
+			rule.MarkupPercent = o.MarkupPercent
+		}
+		if o.FixedMargin != nil {
+			rule.FixedMargin = o.FixedMargin
+		}
+		if o.Shipping != nil {
+			rule.Shipping = o.Shipping
+		}
+		if o.Rounding != "" {
+			rule.Rounding = o.Rounding
+		}
+		if o.MinPrice != nil {
+			rule.MinPrice = o.MinPrice
+		}
+		if o.MaxPrice != nil {
+			rule.MaxPrice = o.MaxPrice
+		}
+		break
+	}
+	return rule
+}
+
+// Price computes the listing price of a product in category. A source
+// price of 0 stays 0 so unpriced products are still refused
+func (rs PricingRules) Price(source float64, category string) float64 {
+	if source <= 0 {
+		return 0
+	}
+	return rs.For(category).Apply(source)
+}
+
+// Apply runs the rule: markup, margin and shipping, then rounding, then the
+// caps
+func (r PricingRule) Apply(source float64) float64 {
+	p := source
+	if r.MarkupPercent != nil {
+		p *= 1 + *r.MarkupPercent/100
+	}
+	if r.FixedMargin != nil {
+		p += *r.FixedMargin
+	}
+	if r.Shipping != nil {
+		p += *r.Shipping
+	}
+	p = math.Round(p*100) / 100
+	switch r.Rounding {
+	case Round99:
+		p = math.Floor(p) + 0.99
+	case Round00:
+		p = math.Ceil(p)
+	}
+	if r.MinPrice != nil && p < *r.MinPrice {
+		p = *r.MinPrice
+	}
+	if r.MaxPrice != nil && p > *r.MaxPrice {
+		p = *r.MaxPrice
+	}
+	return math.Round(p*100) / 100
+}
+
+// PriceQuote is one line of a pricing report
+type PriceQuote struct {
+	ProductID    int64
+	Reference    string
+	Title        string
+	Category     string
+	SourcePrice  float64
+	ListingPrice float64
+}
+
+// PriceReport computes the listing price of every candidate product, the
+// active products with a price, under the rule of its Wallapop category and
+// logs one line per product
+func PriceReport(ctx context.Context, products ports.ProductRepository, rules PricingRules, categories CategoryMap) ([]PriceQuote, error) {
+	candidates, err := products.Candidates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	quotes := make([]PriceQuote, 0, len(candidates))
+	var source, listing float64
+	for i := range candidates {
+		p := &candidates[i]
+		category := categories.For(p)
+		q := PriceQuote{
+			ProductID:    p.ID,
+			Reference:    p.Reference,
+			Title:        p.Title,
+			Category:     category,
+			SourcePrice:  p.Price,
+			ListingPrice: rules.Price(p.Price, category),
+		}
+		quotes = append(quotes, q)
+		source += q.SourcePrice
+		listing += q.ListingPrice
+		slog.Info("Price", "step", "pricing", "product_id", q.ProductID, "reference", q.Reference,
+			"title", q.Title, "category", q.Category, "source_price", q.SourcePrice, "listing_price", q.ListingPrice,
+			"margin", math.Round((q.ListingPrice-q.SourcePrice)*100)/100)
+	}
+	slog.Info("Pricing report finished", "step", "pricing", "products", len(quotes),
+		"source_total", math.Round(source*100)/100, "listing_total", math.Round(listing*100)/100)
+	return quotes, nil
+}
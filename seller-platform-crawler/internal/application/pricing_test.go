@@ -0,0 +1,91 @@
+package application
+
+import (
+	"testing"
+
+	"seller-platform-crawler/internal/domain/model"
+)
+
+func ptr(f float64) *float64 { return &f }
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   PricingRule
+		source float64
+		want   float64
+	}{
+		{name: "empty rule copies the price", source: 19.95, want: 19.95},
+		{name: "markup", rule: PricingRule{MarkupPercent: ptr(25)}, source: 40, want: 50},
+		{name: "margin after markup", rule: PricingRule{MarkupPercent: ptr(10), FixedMargin: ptr(5)}, source: 40, want: 49},
+		{name: "shipping", rule: PricingRule{FixedMargin: ptr(2), Shipping: ptr(3.5)}, source: 10, want: 15.5},
+		{name: "round .99", rule: PricingRule{MarkupPercent: ptr(20), Rounding: Round99}, source: 41, want: 49.99},
+		{name: "round .99 keeps whole euros", rule: PricingRule{Rounding: Round99}, source: 30, want: 30.99},
+		{name: "round .00", rule: PricingRule{MarkupPercent: ptr(20), Rounding: Round00}, source: 41, want: 50},
+		{name: "round .00 keeps whole euros", rule: PricingRule{Rounding: Round00}, source: 30, want: 30},
+		{name: "cents rounded before .00", rule: PricingRule{MarkupPercent: ptr(10), Rounding: Round00}, source: 10, want: 11},
+		{name: "none", rule: PricingRule{MarkupPercent: ptr(15), Rounding: RoundNone}, source: 9.99, want: 11.49},
+		{name: "min cap", rule: PricingRule{MinPrice: ptr(5)}, source: 2.5, want: 5},
+		{name: "max cap", rule: PricingRule{MarkupPercent: ptr(50), MaxPrice: ptr(100)}, source: 90, want: 100},
+		{name: "caps after rounding", rule: PricingRule{Rounding: Round99, MaxPrice: ptr(19.5)}, source: 19.2, want: 19.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.Apply(tt.source); got != tt.want {
+				t.Errorf("Apply(%.2f) = %.2f, want %.2f", tt.source, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrice(t *testing.T) {
+	rules := PricingRules{
+		PricingRule: PricingRule{MarkupPercent: ptr(20), Rounding: Round99},
+		Categories: map[string]PricingRule{
+			"Bricolaje": {MarkupPercent: ptr(50)},
+		},
+	}
+	tests := []struct {
+		name     string
+		source   float64
+		category string
+		want     float64
+	}{
+		{name: "base rule", source: 10, category: "Jardín", want: 12.99},
+		{name: "category overrides the markup", source: 10, category: "bricolaje ", want: 15.99},
+		{name: "unpriced product", source: 0, category: "Bricolaje", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rules.Price(tt.source, tt.category); got != tt.want {
+				t.Errorf("Price(%.2f, %q) = %.2f, want %.2f", tt.source, tt.category, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCategoryMap(t *testing.T) {
+	m := NewCategoryMap("Otros")
+	m.crumbs = map[string]string{
+		"herramientas":  "Bricolaje",
+		"jardín":        "Hogar y jardín",
+		"cortacéspedes": "Jardinería",
+	}
+	tests := []struct {
+		category string
+		want     string
+	}{
+		{category: "Herramientas > Taladros", want: "Bricolaje"},
+		{category: "Jardín > Cortacéspedes", want: "Jardinería"},
+		{category: "Jardín  >  Mangueras", want: "Hogar y jardín"},
+		{category: "Cocina > Grifos", want: "Otros"},
+		{category: "", want: "Otros"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.category, func(t *testing.T) {
+			if got := m.For(&model.Product{Category: tt.category}); got != tt.want {
+				t.Errorf("For(%q) = %q, want %q", tt.category, got, tt.want)
+			}
+		})
+	}
+}
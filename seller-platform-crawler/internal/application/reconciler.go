@@ -0,0 +1,254 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"seller-platform-crawler/internal/domain/model"
+	"seller-platform-crawler/internal/domain/ports"
+)
+
+// What the reconciliation does to a listing whose product ran out
+const (
+	DelistReserve = "reserve"
+	DelistDelete  = "delete"
+)
+
+// Reasons stored on delisted listings
+const (
+	delistedOutOfStock   = "out_of_stock"
+	delistedDiscontinued = "discontinued"
+)
+
+// Button texts on the owner's item page
+var (
+	reserveButtons   = []string{"Reservar"}
+	unreserveButtons = []string{"Anular reserva", "Quitar reserva", "Reservado"}
+	deleteButtons    = []string{"Eliminar"}
+	confirmButtons   = []string{"Sí, eliminar", "Eliminar", "Confirmar"}
+)
+
+// clickButtonScript clicks the first visible button whose text is one of
+// the arguments. The dialog argument limits the search to an open dialog
+const clickButtonScript = `
+	(function(labels, dialog) {
+		const root = dialog ? (document.querySelector('[role="dialog"], walla-modal, .modal') || document) : document;
+		const want = labels.map(l => l.toLowerCase());
+		for (const el of root.querySelectorAll('walla-button, button, [role="button"]')) {
+			const text = (el.getAttribute('text') || el.innerText || '').trim().toLowerCase();
+			if (want.includes(text) && el.offsetParent !== null) {
+				el.click();
+				return true;
+			}
+		}
+		return false;
+	})(%s, %t)
+`
+
+// ReconcileResult counts the outcome of a reconciliation
+type ReconcileResult struct {
+	Checked     int
+	Delisted    int
+	Reactivated int
+	Requeued    int
+	Failed      int
+}
+
+// Reconciler keeps listings in line with the stock of the store we buy from:
+// a listing whose product sold out or was discontinued is reserved or
+// deleted, and comes back when the stock does
+type Reconciler struct {
+	browser  ports.BrowserAutomation
+	products ports.ProductRepository
+	listings ports.MarketplaceListingRepository
+	jobs     ports.ListingJobRepository
+	store    string
+	mode     string
+}
+
+// NewReconciler creates a reconciler. mode is DelistReserve or DelistDelete
+func NewReconciler(browser ports.BrowserAutomation, products ports.ProductRepository, listings ports.MarketplaceListingRepository,
+	jobs ports.ListingJobRepository, store, mode string) (*Reconciler, error) {
+	if mode != DelistReserve && mode != DelistDelete {
+		return nil, fmt.Errorf("delist mode %q, want %s or %s", mode, DelistReserve, DelistDelete)
+	}
+	return &Reconciler{browser: browser, products: products, listings: listings, jobs: jobs, store: store, mode: mode}, nil
+}
+
+// Run reconciles every linked listing. With dryRun the planned actions are
+// only logged. Listings whose availability is unknown are left alone
+func (r *Reconciler) Run(ctx context.Context, dryRun bool) (ReconcileResult, error) {
+	var res ReconcileResult
+	linked, err := r.listings.Linked(ctx)
+	if err != nil {
+		return res, err
+	}
+	for _, l := range linked {
+		res.Checked++
+		lg := slog.With("step", "reconcile", "listing_id", l.ListingID, "product_id", l.ProductID, "status", l.Status)
+		product, err := r.products.GetProduct(ctx, l.ProductID)
+		if err != nil {
+			lg.Warn("Source product unavailable", "err", err)
+			res.Failed++
+			continue
+		}
+		avail, err := r.products.StoreAvailability(ctx, l.ProductID, r.store)
+		if err != nil {
+			return res, err
+		}
+
+		action, reason := r.plan(l, product, avail)
+		if action == "requeue" {
+			// a requeued product keeps its old row until the new upload
+			// publishes, so it must not be queued again meanwhile
+			queued, err := r.jobs.Queued(ctx, l.ProductID)
+			if err != nil {
+				return res, err
+			}
+			if queued {
+				action = ""
+			}
+		}
+		if action == "" {
+			continue
+		}
+		lg.Info("Reconciling listing", "action", action, "reason", reason, "dry_run", dryRun)
+		if dryRun {
+			continue
+		}
+		err = r.apply(ctx, l, action, reason, &res)
+		if action != "requeue" {
+			if rerr := r.browser.ReportResult(err); rerr != nil {
+				return res, errors.Join(err, fmt.Errorf("restart browser: %w", rerr))
+			}
+		}
+		if err != nil {
+			res.Failed++
+			lg.Warn("Reconciliation failed", "action", action, "err", err)
+			if errors.Is(err, ports.ErrSessionExpired) {
+				return res, err
+			}
+			continue
+		}
+		if err := r.browser.Pause(ctx, 8000, 20000); err != nil {
+			return res, err
+		}
+	}
+	slog.Info("Reconciliation finished", "step", "reconcile", "dry_run", dryRun, "checked", res.Checked,
+		"delisted", res.Delisted, "reactivated", res.Reactivated, "requeued", res.Requeued, "failed", res.Failed)
+	return res, nil
+}
+
+// plan decides what to do with a listing: reserve, delete, unreserve or
+// requeue, or nothing
+func (r *Reconciler) plan(l model.LinkedListing, product *model.Product, avail model.StoreAvailability) (action, reason string) {
+	gone := product.Status == model.ProductDiscontinued || product.Status == model.ProductNotFound
+	soldOut := avail.InStock != nil && !*avail.InStock
+	available := !gone && avail.InStock != nil && *avail.InStock
+
+	switch {
+	case (gone || soldOut) && l.Status == model.ListingPublished:
+		reason = delistedOutOfStock
+		if gone {
+			reason = delistedDiscontinued
+		}
+		return r.mode, reason
+	case gone && l.Status == model.ListingReserved && l.DelistedReason == delistedOutOfStock && r.mode == DelistDelete:
+		// a product we reserved while out of stock will not come back
+		return DelistDelete, delistedDiscontinued
+	case available && l.Status == model.ListingReserved && l.DelistedReason != "":
+		return "unreserve", ""
+	case available && l.Status == model.ListingRemoved && l.DelistedReason == delistedOutOfStock:
+		return "requeue", ""
+	}
+	return "", ""
+}
+
+// apply performs the action on Wallapop and records it
+func (r *Reconciler) apply(ctx context.Context, l model.LinkedListing, action, reason string, res *ReconcileResult) error {
+	switch action {
+	case "requeue":
+		// a deleted listing cannot be restored, so the product is uploaded
+		// again. The old row keeps its reason until the new listing is
+		// published, so a failed upload is requeued on a later run
+		if err := r.jobs.Requeue(ctx, l.ProductID, "back in stock"); err != nil {
+			return err
+		}
+		res.Requeued++
+		return nil
+	case DelistReserve:
+		if err := r.onItemPage(ctx, l, reserveButtons, false); err != nil {
+			return err
+		}
+		res.Delisted++
+		return r.listings.SetDelisted(ctx, l.ListingID, model.ListingReserved, reason)
+	case "unreserve":
+		if err := r.onItemPage(ctx, l, unreserveButtons, false); err != nil {
+			return err
+		}
+		res.Reactivated++
+		return r.listings.SetDelisted(ctx, l.ListingID, model.ListingPublished, "")
+	case DelistDelete:
+		if err := r.onItemPage(ctx, l, deleteButtons, true); err != nil {
+			return err
+		}
+		res.Delisted++
+		return r.listings.SetDelisted(ctx, l.ListingID, model.ListingRemoved, reason)
+	}
+	return fmt.Errorf("unknown action %q", action)
+}
+
+// onItemPage opens the listing, clicks one of the buttons and, for
+// destructive actions, the confirmation; it returns once the items API
+// accepted the change
+func (r *Reconciler) onItemPage(ctx context.Context, l model.LinkedListing, buttons []string, confirm bool) error {
+	if l.URL == "" || !strings.Contains(l.URL, "/item/") {
+		return fmt.Errorf("no item page URL for listing %s, run -sync-listings first", l.ListingID)
+	}
+	tabCtx, cancel := r.browser.CreateTabContext(2 * time.Minute)
+	defer cancel()
+	responses := r.browser.WatchResponses(tabCtx, wallapopItemsAPI)
+
+	if err := r.browser.Navigate(tabCtx, l.URL); err != nil {
+		return fmt.Errorf("navigate failed (%s): %w", l.URL, err)
+	}
+	if err := r.browser.Pause(tabCtx, 1500, 3000); err != nil {
+		return err
+	}
+	if url, err := r.browser.CurrentURL(tabCtx); err == nil && strings.Contains(url, "/auth") {
+		return fmt.Errorf("%w: item page redirected to %s", ports.ErrSessionExpired, url)
+	}
+	if err := r.clickButton(tabCtx, buttons, false); err != nil {
+		return err
+	}
+	if confirm {
+		if err := r.browser.Pause(tabCtx, 800, 1500); err != nil {
+			return err
+		}
+		if err := r.clickButton(tabCtx, confirmButtons, true); err != nil {
+			return err
+		}
+	}
+	_, err := awaitItemSaved(tabCtx, responses, "", l.ListingID)
+	return err
+}
+
+func (r *Reconciler) clickButton(ctx context.Context, labels []string, inDialog bool) error {
+	quoted := make([]string, len(labels))
+	for i, l := range labels {
+		quoted[i] = fmt.Sprintf("%q", l)
+	}
+	var clicked bool
+	script := fmt.Sprintf(clickButtonScript, "["+strings.Join(quoted, ", ")+"]", inDialog)
+	if err := r.browser.ExecuteScript(ctx, script, &clicked); err != nil {
+		return err
+	}
+	if !clicked {
+		return fmt.Errorf("button %s not found", strings.Join(quoted, " / "))
+	}
+	return nil
+}
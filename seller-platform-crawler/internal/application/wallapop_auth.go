@@ -2,47 +2,89 @@ package application
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
+	"github.com/chromedp/cdproto/target"
+
+	"seller-platform-crawler/internal/domain/ports"
 	"seller-platform-crawler/internal/infrastructure/browser"
 )
 
-// WallapopAuthService handles authentication with Wallapop
-type WallapopAuthService struct {
+const (
+	wallapopHomeURL      = "https://es.wallapop.com/"
+	wallapopLoginURL     = "https://es.wallapop.com/auth/onboarding?redirectUrl=%2F"
+	wallapopCookieDomain = "wallapop.com"
+)
+
+// sessionCookieNames are only set by Wallapop for a logged-in user
+var sessionCookieNames = map[string]bool{"accessToken": true, "refreshToken": true}
+
+// sessionStateScript reports whether the page shows the signed-in avatar or
+// the signed-out login button. The avatar is looked for in the header and
+// user menu only, item cards show the sellers' avatars
+const sessionStateScript = `
+	(function() {
+		const avatarSel = 'walla-avatar, [data-testid="user-avatar"], img[class*="avatar" i], a[href*="/app/profile"]';
+		let avatar = null;
+		for (const root of document.querySelectorAll('header, tsl-topbar, [role="banner"], [data-testid="user-menu"]')) {
+			avatar = root.querySelector(avatarSel);
+			if (avatar) break;
+		}
+		const login = document.querySelector('walla-button[text="Regístrate o inicia sesión"], walla-button[text="Inicia sesión"], [data-testid="login-button"], a[href*="/auth/onboarding"]');
+		return {avatar: !!avatar, login: !!login};
+	})()
+`
+
+// wallapopSession is the session handling shared by the login strategies:
+// detecting the logged-in state and moving cookies in and out of the store
+type wallapopSession struct {
 	browser *browser.ChromeDPAdapter
+	store   ports.SessionStore
 }
 
+// WallapopAuthService handles authentication with Wallapop through Google
+type WallapopAuthService struct {
+	wallapopSession
+	google GoogleLoginOptions
+}
+
+var _ ports.AuthService = (*WallapopAuthService)(nil)
+
 // NewWallapopAuthService creates a new Wallapop authentication service
 func NewWallapopAuthService(browserAdapter *browser.ChromeDPAdapter) *WallapopAuthService {
 	return &WallapopAuthService{
-		browser: browserAdapter,
+		wallapopSession: wallapopSession{browser: browserAdapter},
+		google:          GoogleLoginOptions{ManualTimeout: 5 * time.Minute},
 	}
 }
 
-// Login performs the login to Wallapop
+// SetSessionStore enables exporting the session cookies after a login and
+// importing them before the session check
+func (s *wallapopSession) SetSessionStore(store ports.SessionStore) {
+	s.store = store
+}
+
+// Login performs the login to Wallapop. It is skipped when the profile or the
+// session store already holds a valid session
 func (s *WallapopAuthService) Login(ctx context.Context) error {
-	// Initialize browser if not already done
-	if err := s.browser.Initialize(); err != nil {
-		return fmt.Errorf("failed to initialize browser: %w", err)
+	if err := s.ensureBrowser(); err != nil {
+		return err
 	}
 
 	// Create a new tab context with timeout
-	tabCtx, cancel := s.browser.CreateTabContext(45 * time.Second)
+	tabCtx, cancel := s.browser.CreateTabContext(s.loginTimeout())
 	defer cancel()
 
-	// Navigate to login page
-	url := "https://es.wallapop.com/auth/onboarding?redirectUrl=%2F"
-	if err := s.browser.Navigate(tabCtx, url); err != nil {
-		return fmt.Errorf("navigate failed (%s): %w", url, err)
+	if valid, err := s.resumeSession(tabCtx); valid || err != nil {
+		return err
 	}
 
-	log.Printf("Navigated to Wallapop login page")
-
-	// Accept cookies if banner is present
-	if err := s.acceptCookies(tabCtx); err != nil {
-		log.Printf("Cookie acceptance failed (non-critical): %v", err)
+	// Navigate to login page
+	if err := s.openLoginPage(tabCtx); err != nil {
+		return err
 	}
 
 	// Click Google login button in iframe
@@ -50,12 +92,161 @@ func (s *WallapopAuthService) Login(ctx context.Context) error {
 		return fmt.Errorf("failed to click Google login: %w", err)
 	}
 
-	log.Printf("Login process completed successfully")
+	return s.finishLogin(tabCtx)
+}
+
+// resumeSession imports the stored session and checks it. valid is true when
+// the login can be skipped
+func (s *wallapopSession) resumeSession(ctx context.Context) (valid bool, err error) {
+	s.restoreSession(ctx)
+	err = s.checkSession(ctx)
+	if err == nil {
+		slog.Info("Existing Wallapop session is valid, skipping login", "step", "session")
+		s.saveSession(ctx)
+		return true, nil
+	}
+	if !errors.Is(err, ports.ErrSessionExpired) {
+		return false, fmt.Errorf("session check failed: %w", err)
+	}
+	slog.Info("No valid session, logging in", "step", "session", "reason", err)
+	return false, nil
+}
+
+// finishLogin waits for the redirect after a login form or popup, verifies
+// the session and saves it
+func (s *wallapopSession) finishLogin(ctx context.Context) error {
+	if err := s.waitForWallapopRedirect(ctx); err != nil {
+		return err
+	}
+	if err := s.checkSession(ctx); err != nil {
+		return fmt.Errorf("login flow finished but no session was detected: %w", err)
+	}
+	s.saveSession(ctx)
+	slog.Info("Login process completed successfully", "step", "login")
+	return nil
+}
+
+// CheckSession reports whether the browser is logged in to Wallapop, after
+// importing the stored session if there is one. It returns an error wrapping
+// ports.ErrSessionExpired when a login is needed
+func (s *wallapopSession) CheckSession(ctx context.Context) error {
+	if err := s.ensureBrowser(); err != nil {
+		return err
+	}
+	tabCtx, cancel := s.browser.CreateTabContext(30 * time.Second)
+	defer cancel()
+	s.restoreSession(tabCtx)
+	return s.checkSession(tabCtx)
+}
+
+// ensureBrowser starts the browser unless it is already running
+func (s *wallapopSession) ensureBrowser() error {
+	if s.browser.GetContext() != nil {
+		return nil
+	}
+	if err := s.browser.Initialize(); err != nil {
+		return fmt.Errorf("failed to initialize browser: %w", err)
+	}
+	return nil
+}
+
+// checkSession loads the home page and looks for the session cookies and the
+// profile avatar
+func (s *wallapopSession) checkSession(ctx context.Context) error {
+	if err := s.browser.Navigate(ctx, wallapopHomeURL); err != nil {
+		return fmt.Errorf("navigate failed (%s): %w", wallapopHomeURL, err)
+	}
+	if err := s.browser.Sleep(1500, 3000).Do(ctx); err != nil {
+		return err
+	}
+
+	var state struct {
+		Avatar bool `json:"avatar"`
+		Login  bool `json:"login"`
+	}
+	if err := s.browser.ExecuteScript(ctx, sessionStateScript, &state); err != nil {
+		return fmt.Errorf("session state script failed: %w", err)
+	}
+	cookies, err := s.browser.ExportCookies(ctx, wallapopCookieDomain)
+	if err != nil {
+		return fmt.Errorf("read cookies failed: %w", err)
+	}
+	hasCookie := false
+	for _, c := range cookies {
+		if sessionCookieNames[c.Name] && c.Value != "" {
+			hasCookie = true
+			break
+		}
+	}
+	slog.Debug("Session state", "step", "session", "avatar", state.Avatar, "login_button", state.Login, "session_cookie", hasCookie)
+
+	switch {
+	case state.Avatar && !state.Login:
+		return nil
+	case hasCookie && !state.Login:
+		return nil
+	case hasCookie:
+		return fmt.Errorf("%w: session cookie present but page shows login", ports.ErrSessionExpired)
+	default:
+		return fmt.Errorf("%w: no session cookie", ports.ErrSessionExpired)
+	}
+}
+
+// restoreSession imports stored cookies. Failures only cost a login, so they
+// are logged and ignored
+func (s *wallapopSession) restoreSession(ctx context.Context) {
+	if s.store == nil {
+		return
+	}
+	cookies, err := s.store.Load()
+	if err != nil {
+		slog.Warn("Session import failed", "step", "session", "err", err)
+		return
+	}
+	if len(cookies) == 0 {
+		return
+	}
+	if err := s.browser.ImportCookies(ctx, cookies); err != nil {
+		slog.Warn("Session import failed", "step", "session", "err", err)
+		return
+	}
+	slog.Debug("Imported session cookies", "step", "session", "cookies", len(cookies))
+}
+
+// saveSession exports the current Wallapop cookies to the store
+func (s *wallapopSession) saveSession(ctx context.Context) {
+	if s.store == nil {
+		return
+	}
+	cookies, err := s.browser.ExportCookies(ctx, wallapopCookieDomain)
+	if err == nil {
+		err = s.store.Save(cookies)
+	}
+	if err != nil {
+		slog.Warn("Session export failed", "step", "session", "err", err)
+		return
+	}
+	slog.Debug("Exported session cookies", "step", "session", "cookies", len(cookies))
+}
+
+// openLoginPage navigates to the onboarding page and dismisses the cookie
+// banner
+func (s *wallapopSession) openLoginPage(ctx context.Context) error {
+	if err := s.browser.Navigate(ctx, wallapopLoginURL); err != nil {
+		return fmt.Errorf("navigate failed (%s): %w", wallapopLoginURL, err)
+	}
+
+	slog.Info("Navigated to Wallapop login page", "step", "navigate", "url", wallapopLoginURL)
+
+	// Accept cookies if banner is present
+	if err := s.acceptCookies(ctx); err != nil {
+		slog.Warn("Cookie acceptance failed (non-critical)", "step", "cookies", "err", err)
+	}
 	return nil
 }
 
 // acceptCookies attempts to accept the cookie banner
-func (s *WallapopAuthService) acceptCookies(ctx context.Context) error {
+func (s *wallapopSession) acceptCookies(ctx context.Context) error {
 	cookieBtnSel := `#onetrust-accept-btn-handler`
 	
 	// Add human-like delay
@@ -70,7 +261,7 @@ func (s *WallapopAuthService) acceptCookies(ctx context.Context) error {
 	}
 
 	if !visible {
-		log.Printf("Cookie button not found, skipping...")
+		slog.Debug("Cookie button not found, skipping", "step", "cookies")
 		return nil
 	}
 
@@ -79,7 +270,7 @@ func (s *WallapopAuthService) acceptCookies(ctx context.Context) error {
 		return err
 	}
 
-	log.Printf("Cookies banner accepted successfully")
+	slog.Info("Cookies banner accepted successfully", "step", "cookies")
 	return nil
 }
 
@@ -93,7 +284,12 @@ func (s *WallapopAuthService) clickGoogleLogin(ctx context.Context) error {
 		return fmt.Errorf("Google login button not found: %w", err)
 	}
 
-	log.Printf("Found Google Sign-In button")
+	slog.Debug("Found Google Sign-In button", "step", "google_login")
+
+	// The popup must be watched for before the click that opens it
+	waitCtx, cancelWait := context.WithTimeout(ctx, popupOpenTimeout)
+	defer cancelWait()
+	popupCh := s.browser.WaitForPopup(waitCtx, googleAccountsHost)
 
 	// Click the button using JavaScript since it's a web component
 	clickScript := `
@@ -114,18 +310,22 @@ func (s *WallapopAuthService) clickGoogleLogin(ctx context.Context) error {
 		return fmt.Errorf("failed to click Google button")
 	}
 
-	log.Printf("Google login button clicked successfully")
-	
-	// TODO aleks: Handle Google login popup here
-	// Add a small delay to allow the click to process
-	if err := s.browser.Sleep(5000, 10000).Do(ctx); err != nil {
-		return err
+	slog.Info("Google login button clicked successfully", "step", "google_login")
+
+	var popupID target.ID
+	select {
+	case popupID = <-popupCh:
+	case <-waitCtx.Done():
+		return fmt.Errorf("google popup did not open within %s", popupOpenTimeout)
 	}
+	slog.Info("Attached to Google popup", "step", "google_login", "target", popupID)
 
-	return nil
+	popupTab, cancelPopup := s.browser.AttachToTarget(ctx, popupID)
+	defer cancelPopup()
+	return s.driveGooglePopup(popupTab)
 }
 
 // Close cleans up browser resources
-func (s *WallapopAuthService) Close() error {
+func (s *wallapopSession) Close() error {
 	return s.browser.Close()
 }
@@ -0,0 +1,126 @@
+package model
+
+// Product is an Obramat product as stored by the consumer crawler
+type Product struct {
+	ID          int64
+	SourceURL   string
+	Reference   string
+	Title       string
+	Description string
+	Price       float64
+	Currency    string
+	Brand       string
+	Model       string
+	EAN         string
+	// Category is the Obramat breadcrumb, e.g. "Herramientas > Taladros"
+	Category string
+	// Status is the lifecycle status of the product page, e.g. active or
+	// discontinued
+	Status string
+	// Images are the product photo URLs in carousel order
+	Images []string
+	// Specs are the technical characteristics listed on the product page
+	Specs []Spec
+}
+
+// Spec is one row of a product's technical characteristics
+type Spec struct {
+	Name  string
+	Value string
+}
+
+// Listing is an item published on Wallapop
+type Listing struct {
+	ID  string
+	URL string
+}
+
+// Product lifecycle states written by the consumer crawler
+const (
+	ProductActive       = "active"
+	ProductDiscontinued = "discontinued"
+	ProductNotFound     = "not_found"
+)
+
+// StoreAvailability is the stock of a product in one store
+type StoreAvailability struct {
+	// Stock is nil when the store gives no unit count
+	Stock *int
+	// InStock is nil when availability is unknown
+	InStock *bool
+}
+
+// Listing job states
+const (
+	JobPending   = "pending"
+	JobUploading = "uploading"
+	JobPublished = "published"
+	JobFailed    = "failed"
+)
+
+// ListingJob is the upload of one product in the bulk queue
+type ListingJob struct {
+	ID        int64
+	ProductID int64
+	Status    string
+	Attempts  int
+	Reason    string
+	Listing   *Listing
+}
+
+// Marketplace listing states
+const (
+	ListingPublished = "published"
+	ListingReserved  = "reserved"
+	ListingSold      = "sold"
+	// ListingRemoved is a listing that a full sync no longer found
+	ListingRemoved = "removed"
+)
+
+// MarketplaceListing is one of our items as Wallapop shows it
+type MarketplaceListing struct {
+	ListingID  string
+	Title      string
+	Price      float64
+	Currency   string
+	Views      int
+	Favourites int
+	Status     string
+	URL        string
+	// ProductID is the Obramat product the listing resells, 0 if unknown
+	ProductID int64
+}
+
+// ListingSubmission is what we sent to Wallapop for a listing, with the
+// source product's price and stock at that time
+type ListingSubmission struct {
+	ListingID   string
+	URL         string
+	ProductID   int64
+	Title       string
+	Description string
+	// DescriptionKey identifies the inputs a generated description was
+	// written from; empty when the description was not generated
+	DescriptionKey string
+	Price          float64
+	// Photos are the source image URLs the listing photos came from
+	Photos      []string
+	SourcePrice float64
+	// SourceStock is nil when the store stock was unknown
+	SourceStock *int
+}
+
+// LinkedListing is a live listing with its source product and the last
+// submission, if we made one
+type LinkedListing struct {
+	ListingID string
+	ProductID int64
+	Status    string
+	URL       string
+	// DelistedReason is set while the listing is reserved or removed by
+	// the stock reconciliation, e.g. out_of_stock
+	DelistedReason string
+	// Price is the price Wallapop shows
+	Price      float64
+	Submission *ListingSubmission
+}
@@ -5,4 +5,15 @@ import "context"
 // AuthService defines the interface for authentication operations
 type AuthService interface {
 	Login(ctx context.Context) error
+	// CheckSession returns nil when the browser is logged in and an error
+	// wrapping ErrSessionExpired when it is not
+	CheckSession(ctx context.Context) error
+}
+
+// Credentials are the secrets the login strategies read. Each strategy uses
+// only the fields it needs
+type Credentials struct {
+	Email         string `json:"email"`
+	Password      string `json:"password"`
+	GoogleAccount string `json:"google_account"`
 }
@@ -1,6 +1,14 @@
 package ports
 
-import "context"
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNavigation marks a page that failed to load, the failure that counts
+// against a proxy
+var ErrNavigation = errors.New("navigation failed")
 
 // BrowserAutomation defines the interface for browser automation operations
 type BrowserAutomation interface {
@@ -8,5 +16,31 @@ type BrowserAutomation interface {
 	ClickElement(ctx context.Context, selector string) error
 	WaitForElement(ctx context.Context, selector string) error
 	ExecuteScript(ctx context.Context, script string, result interface{}) error
+	// TypeText types text into an input with key events
+	TypeText(ctx context.Context, selector, text string) error
+	// ClearInput empties an input or textarea the way a user would
+	ClearInput(ctx context.Context, selector string) error
+	// SetUploadFiles sets the files of an <input type="file">
+	SetUploadFiles(ctx context.Context, selector string, files []string) error
+	// Pause waits a random human-like delay between minMs and maxMs
+	Pause(ctx context.Context, minMs, maxMs int) error
+	// CurrentURL returns the URL of the page loaded in the tab
+	CurrentURL(ctx context.Context) (string, error)
+	// WatchResponses delivers the responses whose URL contains urlPart,
+	// until ctx is done; Body is nil when it could not be read. It must be
+	// called before the request is made
+	WatchResponses(ctx context.Context, urlPart string) <-chan NetworkResponse
+	CreateTabContext(timeout time.Duration) (context.Context, context.CancelFunc)
+	// ReportResult feeds the outcome of an operation back into the proxy
+	// pool. It must be called with no tab open, as it may restart the browser
+	ReportResult(err error) error
 	Close() error
 }
+
+// NetworkResponse is a response seen by the browser
+type NetworkResponse struct {
+	URL    string
+	Method string
+	Status int64
+	Body   []byte
+}
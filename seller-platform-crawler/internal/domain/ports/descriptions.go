@@ -0,0 +1,24 @@
+package ports
+
+import (
+	"context"
+
+	"seller-platform-crawler/internal/domain/model"
+)
+
+// DescriptionRequest is what a listing description is written from
+type DescriptionRequest struct {
+	Product *model.Product
+	// Category and Condition are the values picked in the upload form
+	Category  string
+	Condition string
+	// Price is the listing price, not the source price
+	Price float64
+	// MaxLength is the longest description Wallapop accepts, in characters
+	MaxLength int
+}
+
+// DescriptionGenerator writes the description of a listing
+type DescriptionGenerator interface {
+	Generate(ctx context.Context, req DescriptionRequest) (string, error)
+}
@@ -0,0 +1,37 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"seller-platform-crawler/internal/domain/model"
+)
+
+// ListingJobRepository is the bulk upload queue. There is at most one job
+// per product, so a product is never queued twice
+type ListingJobRepository interface {
+	// Enqueue adds pending jobs for products that have none and returns how
+	// many were added
+	Enqueue(ctx context.Context, productIDs []int64) (int, error)
+	// Claim marks the oldest pending job as uploading and returns it, or nil
+	// when the queue is empty
+	Claim(ctx context.Context) (*model.ListingJob, error)
+	MarkPublished(ctx context.Context, jobID int64, listing *model.Listing) error
+	MarkFailed(ctx context.Context, jobID int64, reason string) error
+	// MarkInterrupted fails a job whose upload form was submitted without
+	// confirmation; its listing may be live
+	MarkInterrupted(ctx context.Context, jobID int64, reason string) error
+	// RetryFailed moves failed jobs back to pending. Jobs interrupted in the
+	// middle of an upload may already be live and are only retried when
+	// includeInterrupted is set
+	RetryFailed(ctx context.Context, includeInterrupted bool) (int, error)
+	// FailInterrupted marks jobs left uploading by a previous run as failed,
+	// leaving alone those started within olderThan, which another worker may
+	// still be uploading
+	FailInterrupted(ctx context.Context, olderThan time.Duration) (int, error)
+	// Requeue queues a product again whose listing was removed, creating
+	// the job if there is none
+	Requeue(ctx context.Context, productID int64, reason string) error
+	// Queued reports whether the product has a pending or uploading job
+	Queued(ctx context.Context, productID int64) (bool, error)
+}
@@ -0,0 +1,28 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"seller-platform-crawler/internal/domain/model"
+)
+
+// MarketplaceListingRepository stores our listings as last seen on Wallapop
+type MarketplaceListingRepository interface {
+	// Upsert stores the listing as seen by the sync started at syncedAt and
+	// links it to its source product when one is known, returning the
+	// product id (0 if none)
+	Upsert(ctx context.Context, l model.MarketplaceListing, syncedAt time.Time) (int64, error)
+	// MarkMissing sets listings not seen by the sync started at syncedAt to
+	// removed and returns how many changed
+	MarkMissing(ctx context.Context, syncedAt time.Time) (int, error)
+	// RecordSubmission stores what was submitted for a listing, creating
+	// the row when the listing was just published
+	RecordSubmission(ctx context.Context, s model.ListingSubmission) error
+	// Linked returns the listings that have a source product and are
+	// published, reserved, or removed by the stock reconciliation
+	Linked(ctx context.Context) ([]model.LinkedListing, error)
+	// SetDelisted records a status change made by the stock
+	// reconciliation; an empty reason clears the delisting
+	SetDelisted(ctx context.Context, listingID, status, reason string) error
+}
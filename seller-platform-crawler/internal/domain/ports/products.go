@@ -0,0 +1,30 @@
+package ports
+
+import (
+	"context"
+	"errors"
+
+	"seller-platform-crawler/internal/domain/model"
+)
+
+// ErrProductNotFound is returned when a product id does not exist
+var ErrProductNotFound = errors.New("product not found")
+
+// ProductRepository reads the products collected by the consumer crawler
+type ProductRepository interface {
+	GetProduct(ctx context.Context, id int64) (*model.Product, error)
+	// StoreStock returns the stock of a product in the stores whose city or
+	// name contains store, or nil when no stock figure is known
+	StoreStock(ctx context.Context, productID int64, store string) (*int, error)
+	// StoreAvailability tells whether the matching stores have the product
+	StoreAvailability(ctx context.Context, productID int64, store string) (model.StoreAvailability, error)
+	// Candidates lists the active products with a price, without images
+	Candidates(ctx context.Context) ([]model.Product, error)
+}
+
+// ImageFetcher downloads product photos so they can be uploaded
+type ImageFetcher interface {
+	// Download saves each URL into dir and returns the file paths in the
+	// same order, skipping images that could not be fetched
+	Download(ctx context.Context, urls []string, dir string) ([]string, error)
+}
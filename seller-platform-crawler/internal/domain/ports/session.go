@@ -0,0 +1,35 @@
+package ports
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrSessionExpired is returned when there is no logged-in Wallapop session
+// and an interactive login is needed. Callers check it with errors.Is
+var ErrSessionExpired = errors.New("wallapop session expired")
+
+// SessionCookie is a browser cookie as persisted between runs
+type SessionCookie struct {
+	Name     string  `json:"name"`
+	Value    string  `json:"value"`
+	Domain   string  `json:"domain"`
+	Path     string  `json:"path"`
+	Expires  float64 `json:"expires"`
+	HTTPOnly bool    `json:"http_only"`
+	Secure   bool    `json:"secure"`
+	SameSite string  `json:"same_site,omitempty"`
+}
+
+// SessionStore persists session cookies between runs
+type SessionStore interface {
+	Save(cookies []SessionCookie) error
+	// Load returns (nil, nil) when no session has been saved yet
+	Load() ([]SessionCookie, error)
+}
+
+// SessionBrowser moves session cookies in and out of a browser
+type SessionBrowser interface {
+	ExportCookies(ctx context.Context, domain string) ([]SessionCookie, error)
+	ImportCookies(ctx context.Context, cookies []SessionCookie) error
+}
@@ -2,21 +2,30 @@ package browser
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"math/rand"
 	"os"
 	"time"
 
+	"github.com/chromedp/cdproto/fetch"
 	"github.com/chromedp/chromedp"
+
+	"seller-platform-crawler/internal/domain/ports"
 )
 
 // ChromeDPAdapter is an adapter for chromedp browser automation
 type ChromeDPAdapter struct {
 	allocCtx      context.Context
+	allocCancel   context.CancelFunc
 	ctx           context.Context
 	cancel        context.CancelFunc
 	userDataDir   string
 	headless      bool
+	rotator       *Rotator
+	proxy         *Proxy
+	fingerprint   *Fingerprint
 }
 
 // NewChromeDPAdapter creates a new chromedp adapter
@@ -27,27 +36,71 @@ func NewChromeDPAdapter(userDataDir string, headless bool) *ChromeDPAdapter {
 	}
 }
 
+// SetRotator enables proxy and fingerprint rotation. The profile's cookies
+// live in the default browser context, so rotation happens per browser
+// launch: each Initialize picks the next proxy and a fingerprint
+func (a *ChromeDPAdapter) SetRotator(r *Rotator) {
+	a.rotator = r
+}
+
 // Initialize sets up the browser context
 func (a *ChromeDPAdapter) Initialize() error {
+	userAgent := "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+	var extra []chromedp.ExecAllocatorOption
+	if a.rotator != nil {
+		proxy, err := a.rotator.NextProxy()
+		if err != nil {
+			return err
+		}
+		a.proxy = proxy
+		a.fingerprint = a.rotator.NextFingerprint()
+		if proxy != nil {
+			extra = append(extra, chromedp.ProxyServer(proxy.Server))
+			slog.Info("Using proxy", "proxy", proxy.Server)
+		}
+		if a.fingerprint != nil && a.fingerprint.UserAgent != "" {
+			userAgent = a.fingerprint.UserAgent
+		}
+	}
+
 	opts := append(chromedp.DefaultExecAllocatorOptions[:],
 		chromedp.Flag("headless", a.headless),
 		chromedp.UserDataDir(os.ExpandEnv(a.userDataDir)),
 		chromedp.Flag("disable-blink-features", "AutomationControlled"),
 		chromedp.Flag("start-maximized", true),
-		chromedp.UserAgent("Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"),
+		chromedp.UserAgent(userAgent),
 	)
+	opts = append(opts, extra...)
 	
-	var cancel context.CancelFunc
-	a.allocCtx, cancel = chromedp.NewExecAllocator(context.Background(), opts...)
-	_ = cancel // We'll manage this separately
-	
+	a.allocCtx, a.allocCancel = chromedp.NewExecAllocator(context.Background(), opts...)
 	a.ctx, a.cancel = chromedp.NewContext(a.allocCtx)
 	return nil
 }
 
+// ReportResult feeds the outcome of an operation back into the proxy pool.
+// Only navigation errors count against the proxy; a missing selector is the
+// page's fault. When the current proxy gets retired the browser is restarted
+// on the next one
+func (a *ChromeDPAdapter) ReportResult(err error) error {
+	if a.rotator == nil || a.proxy == nil {
+		return nil
+	}
+	a.rotator.Report(a.proxy, errors.Is(err, ports.ErrNavigation))
+	if !a.rotator.Retired(a.proxy) {
+		return nil
+	}
+	if closeErr := a.Close(); closeErr != nil {
+		return closeErr
+	}
+	return a.Initialize()
+}
+
 // Navigate navigates to a URL
 func (a *ChromeDPAdapter) Navigate(ctx context.Context, url string) error {
-	return chromedp.Run(ctx, chromedp.Navigate(url))
+	if err := chromedp.Run(ctx, chromedp.Navigate(url)); err != nil {
+		return fmt.Errorf("%w: %w", ports.ErrNavigation, err)
+	}
+	return nil
 }
 
 // ClickElement clicks on an element
@@ -73,6 +126,9 @@ func (a *ChromeDPAdapter) Close() error {
 	if a.cancel != nil {
 		a.cancel()
 	}
+	if a.allocCancel != nil {
+		a.allocCancel()
+	}
 	return nil
 }
 
@@ -85,6 +141,7 @@ func (a *ChromeDPAdapter) GetContext() context.Context {
 func (a *ChromeDPAdapter) CreateTabContext(timeout time.Duration) (context.Context, context.CancelFunc) {
 	tabCtx, tabCancel := chromedp.NewContext(a.ctx)
 	timeoutCtx, timeoutCancel := context.WithTimeout(tabCtx, timeout)
+	a.prepareTarget(tabCtx, timeoutCtx)
 	
 	// Return a combined cancel function
 	cancelFunc := func() {
@@ -95,6 +152,26 @@ func (a *ChromeDPAdapter) CreateTabContext(timeout time.Duration) (context.Conte
 	return timeoutCtx, cancelFunc
 }
 
+// prepareTarget applies proxy auth and fingerprint overrides to the target
+// behind listenCtx; runCtx bounds the setup
+func (a *ChromeDPAdapter) prepareTarget(listenCtx, runCtx context.Context) {
+	var actions chromedp.Tasks
+	if a.proxyNeedsAuth() {
+		listenProxyAuth(listenCtx, a.proxy)
+		actions = append(actions, fetch.Enable().WithHandleAuthRequests(true))
+	}
+	actions = append(actions, fingerprintActions(a.fingerprint)...)
+	if len(actions) > 0 {
+		if err := chromedp.Run(runCtx, actions); err != nil {
+			slog.Warn("Failed to apply rotation to tab", "err", err)
+		}
+	}
+}
+
+func (a *ChromeDPAdapter) proxyNeedsAuth() bool {
+	return a.proxy != nil && a.proxy.Username != ""
+}
+
 // ClickElementInIframe clicks on an element inside an iframe
 func (a *ChromeDPAdapter) ClickElementInIframe(ctx context.Context, iframeSelector, elementSelector string) error {
 	// Wait for iframe to be visible
@@ -129,9 +206,16 @@ func (a *ChromeDPAdapter) ClickElementInIframe(ctx context.Context, iframeSelect
 
 // Sleep adds a random human-like delay
 func (a *ChromeDPAdapter) Sleep(minMs, maxMs int) chromedp.Action {
+	return chromedp.Sleep(jitter(minMs, maxMs))
+}
+
+// jitter picks a random duration between minMs and maxMs
+func jitter(minMs, maxMs int) time.Duration {
+	if maxMs <= minMs {
+		return time.Duration(minMs) * time.Millisecond
+	}
 	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
-	jitter := time.Duration(minMs+rnd.Intn(maxMs-minMs)) * time.Millisecond
-	return chromedp.Sleep(jitter)
+	return time.Duration(minMs+rnd.Intn(maxMs-minMs)) * time.Millisecond
 }
 
 // IsElementVisible checks if an element is visible
@@ -0,0 +1,128 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"github.com/chromedp/chromedp/kb"
+
+	"seller-platform-crawler/internal/domain/ports"
+)
+
+var _ ports.BrowserAutomation = (*ChromeDPAdapter)(nil)
+
+// SetUploadFiles sets the files of an <input type="file">. The input may be
+// hidden behind a styled drop zone, so it is not waited on to be visible
+func (a *ChromeDPAdapter) SetUploadFiles(ctx context.Context, selector string, files []string) error {
+	return chromedp.Run(ctx, chromedp.SetUploadFiles(selector, files, chromedp.ByQuery, chromedp.NodeReady))
+}
+
+// ClearInput selects the content of an input and deletes it, so frameworks
+// listening to key events see the change
+func (a *ChromeDPAdapter) ClearInput(ctx context.Context, selector string) error {
+	return chromedp.Run(ctx, chromedp.Tasks{
+		chromedp.WaitVisible(selector, chromedp.ByQuery),
+		chromedp.Focus(selector, chromedp.ByQuery),
+		chromedp.Evaluate(fmt.Sprintf(`(function() {
+			const el = document.querySelector(%q);
+			if (el && el.select) el.select();
+			return true;
+		})()`, selector), nil),
+		chromedp.KeyEvent(kb.Backspace),
+		chromedp.Evaluate(fmt.Sprintf(`(function() {
+			const el = document.querySelector(%q);
+			if (el && el.value !== '') {
+				el.value = '';
+				el.dispatchEvent(new Event('input', {bubbles: true}));
+			}
+			return true;
+		})()`, selector), nil),
+	})
+}
+
+// Pause waits a random human-like delay, like Sleep. It does not need a tab,
+// so it also paces work between tabs
+func (a *ChromeDPAdapter) Pause(ctx context.Context, minMs, maxMs int) error {
+	t := time.NewTimer(jitter(minMs, maxMs))
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// CurrentURL returns the URL of the page loaded in the tab
+func (a *ChromeDPAdapter) CurrentURL(ctx context.Context) (string, error) {
+	var url string
+	err := chromedp.Run(ctx, chromedp.Location(&url))
+	return url, err
+}
+
+// WatchResponses delivers the responses whose URL contains urlPart, with
+// their body, until ctx is done. Responses are dropped if the channel is not
+// drained
+func (a *ChromeDPAdapter) WatchResponses(ctx context.Context, urlPart string) <-chan ports.NetworkResponse {
+	ch := make(chan ports.NetworkResponse, 16)
+	var mu sync.Mutex
+	pending := map[network.RequestID]ports.NetworkResponse{}
+	methods := map[network.RequestID]string{}
+
+	chromedp.ListenTarget(ctx, func(ev any) {
+		switch ev := ev.(type) {
+		case *network.EventRequestWillBeSent:
+			if strings.Contains(ev.Request.URL, urlPart) {
+				mu.Lock()
+				methods[ev.RequestID] = ev.Request.Method
+				mu.Unlock()
+			}
+		case *network.EventResponseReceived:
+			if !strings.Contains(ev.Response.URL, urlPart) {
+				return
+			}
+			mu.Lock()
+			pending[ev.RequestID] = ports.NetworkResponse{
+				URL:    ev.Response.URL,
+				Method: methods[ev.RequestID],
+				Status: ev.Response.Status,
+			}
+			mu.Unlock()
+		case *network.EventLoadingFinished:
+			mu.Lock()
+			resp, ok := pending[ev.RequestID]
+			delete(pending, ev.RequestID)
+			delete(methods, ev.RequestID)
+			mu.Unlock()
+			if !ok {
+				return
+			}
+			// the body must be fetched outside the event handler
+			go func() {
+				err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+					body, err := network.GetResponseBody(ev.RequestID).Do(ctx)
+					resp.Body = body
+					return err
+				}))
+				if err != nil {
+					slog.Debug("Response body unavailable", "url", resp.URL, "err", err)
+				}
+				// block rather than drop, so a slow reader misses nothing
+				select {
+				case ch <- resp:
+				case <-ctx.Done():
+				}
+			}()
+		}
+	})
+	if err := chromedp.Run(ctx, network.Enable()); err != nil {
+		slog.Warn("Failed to enable network events", "err", err)
+	}
+	return ch
+}
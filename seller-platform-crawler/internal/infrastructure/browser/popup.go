@@ -0,0 +1,56 @@
+package browser
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/chromedp/cdproto/target"
+	"github.com/chromedp/chromedp"
+)
+
+// WaitForPopup returns a channel that receives the id of the next window
+// opened by the tab in ctx whose URL contains urlPart. It must be called
+// before the action that opens the popup
+func (a *ChromeDPAdapter) WaitForPopup(ctx context.Context, urlPart string) <-chan target.ID {
+	var opener target.ID
+	if c := chromedp.FromContext(ctx); c != nil && c.Target != nil {
+		opener = c.Target.TargetID
+	}
+	return chromedp.WaitNewTarget(ctx, func(info *target.Info) bool {
+		if opener != "" && info.OpenerID != opener {
+			return false
+		}
+		return strings.Contains(info.URL, urlPart)
+	})
+}
+
+// AttachToTarget returns a context that drives an existing target such as a
+// popup, with the tab's proxy auth and fingerprint applied. The context is
+// done once the target closes
+func (a *ChromeDPAdapter) AttachToTarget(ctx context.Context, id target.ID) (context.Context, context.CancelFunc) {
+	targetCtx, cancel := chromedp.NewContext(ctx, chromedp.WithTargetID(id))
+	chromedp.ListenBrowser(ctx, func(ev any) {
+		if ev, ok := ev.(*target.EventTargetDestroyed); ok && ev.TargetID == id {
+			cancel()
+		}
+	})
+	a.prepareTarget(targetCtx, targetCtx)
+	if a.proxyNeedsAuth() {
+		// the popup's first request was challenged before the auth handler
+		// existed, so load it again
+		if err := chromedp.Run(targetCtx, chromedp.Reload()); err != nil {
+			slog.Warn("Failed to reload popup behind the proxy", "err", err)
+		}
+	}
+	return targetCtx, cancel
+}
+
+// TypeText focuses the element and types text into it with key events
+func (a *ChromeDPAdapter) TypeText(ctx context.Context, selector, text string) error {
+	return chromedp.Run(ctx, chromedp.Tasks{
+		chromedp.WaitVisible(selector, chromedp.ByQuery),
+		chromedp.Focus(selector, chromedp.ByQuery),
+		chromedp.SendKeys(selector, text, chromedp.ByQuery),
+	})
+}
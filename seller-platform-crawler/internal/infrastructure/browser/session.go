@@ -0,0 +1,72 @@
+package browser
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/storage"
+	"github.com/chromedp/chromedp"
+
+	"seller-platform-crawler/internal/domain/ports"
+)
+
+// ExportCookies returns the browser's unexpired cookies whose domain ends in
+// domain, e.g. "wallapop.com"
+func (a *ChromeDPAdapter) ExportCookies(ctx context.Context, domain string) ([]ports.SessionCookie, error) {
+	var cookies []*network.Cookie
+	if err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		cookies, err = storage.GetCookies().Do(ctx)
+		return err
+	})); err != nil {
+		return nil, err
+	}
+	now := float64(time.Now().Unix())
+	var out []ports.SessionCookie
+	for _, c := range cookies {
+		if !strings.HasSuffix(strings.TrimPrefix(c.Domain, "."), domain) {
+			continue
+		}
+		if !c.Session && c.Expires > 0 && c.Expires < now {
+			continue
+		}
+		out = append(out, ports.SessionCookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   c.Domain,
+			Path:     c.Path,
+			Expires:  c.Expires,
+			HTTPOnly: c.HTTPOnly,
+			Secure:   c.Secure,
+			SameSite: string(c.SameSite),
+		})
+	}
+	return out, nil
+}
+
+// ImportCookies installs previously exported cookies into the browser
+func (a *ChromeDPAdapter) ImportCookies(ctx context.Context, cookies []ports.SessionCookie) error {
+	params := make([]*network.CookieParam, 0, len(cookies))
+	for _, c := range cookies {
+		p := &network.CookieParam{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   c.Domain,
+			Path:     c.Path,
+			HTTPOnly: c.HTTPOnly,
+			Secure:   c.Secure,
+			SameSite: network.CookieSameSite(c.SameSite),
+		}
+		if c.Expires > 0 {
+			expires := cdp.TimeSinceEpoch(time.Unix(int64(c.Expires), 0))
+			p.Expires = &expires
+		}
+		params = append(params, p)
+	}
+	return chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		return storage.SetCookies(params).Do(ctx)
+	}))
+}
@@ -0,0 +1,157 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"seller-platform-crawler/internal/domain/model"
+	"seller-platform-crawler/internal/domain/ports"
+)
+
+// interruptedReason marks jobs a previous run left in the uploading state
+const interruptedReason = "interrupted during upload, the listing may be live"
+
+// ListingJobRepository keeps the bulk upload queue in listing_jobs
+type ListingJobRepository struct {
+	db *sql.DB
+}
+
+// NewListingJobRepository creates a repository on an open database
+func NewListingJobRepository(db *sql.DB) *ListingJobRepository {
+	return &ListingJobRepository{db: db}
+}
+
+var _ ports.ListingJobRepository = (*ListingJobRepository)(nil)
+
+// Enqueue inserts a pending job for each product without one
+func (r *ListingJobRepository) Enqueue(ctx context.Context, productIDs []int64) (int, error) {
+	added := 0
+	for _, id := range productIDs {
+		res, err := r.db.ExecContext(ctx, `INSERT IGNORE INTO listing_jobs (product_id, status) VALUES (?, ?)`, id, model.JobPending)
+		if err != nil {
+			return added, err
+		}
+		n, _ := res.RowsAffected()
+		added += int(n)
+	}
+	return added, nil
+}
+
+// Claim takes the oldest pending job in a transaction so two workers never
+// upload the same product
+func (r *ListingJobRepository) Claim(ctx context.Context) (*model.ListingJob, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	job := &model.ListingJob{Status: model.JobUploading}
+	err = tx.QueryRowContext(ctx, `
+		SELECT id, product_id, attempts FROM listing_jobs
+		WHERE status = ? ORDER BY id LIMIT 1 FOR UPDATE
+	`, model.JobPending).Scan(&job.ID, &job.ProductID, &job.Attempts)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	job.Attempts++
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE listing_jobs SET status = ?, attempts = ?, reason = NULL, started_at = NOW(), finished_at = NULL
+		WHERE id = ?
+	`, model.JobUploading, job.Attempts, job.ID); err != nil {
+		return nil, err
+	}
+	return job, tx.Commit()
+}
+
+// MarkPublished records the created listing. A listing the reconciler
+// deleted and requeued is superseded by it, so its delisted reason is cleared
+func (r *ListingJobRepository) MarkPublished(ctx context.Context, jobID int64, listing *model.Listing) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE listing_jobs SET status = ?, reason = NULL, listing_id = ?, listing_url = ?, finished_at = NOW()
+		WHERE id = ?
+	`, model.JobPublished, listing.ID, listing.URL, jobID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE marketplace_listings SET delisted_reason = NULL
+		WHERE status = ? AND delisted_reason IS NOT NULL AND listing_id <> ?
+		  AND product_id = (SELECT product_id FROM listing_jobs WHERE id = ?)
+	`, model.ListingRemoved, listing.ID, jobID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// MarkFailed records why an upload failed
+func (r *ListingJobRepository) MarkFailed(ctx context.Context, jobID int64, reason string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE listing_jobs SET status = ?, reason = ?, finished_at = NOW() WHERE id = ?
+	`, model.JobFailed, reason, jobID)
+	return err
+}
+
+// MarkInterrupted records an upload that failed after the form was
+// submitted, so the listing may be live; RetryFailed skips it like a job a
+// crashed run left uploading
+func (r *ListingJobRepository) MarkInterrupted(ctx context.Context, jobID int64, reason string) error {
+	return r.MarkFailed(ctx, jobID, interruptedReason+": "+reason)
+}
+
+// RetryFailed moves failed jobs back to pending
+func (r *ListingJobRepository) RetryFailed(ctx context.Context, includeInterrupted bool) (int, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE listing_jobs SET status = ?
+		WHERE status = ? AND listing_id IS NULL AND (? OR COALESCE(reason, '') NOT LIKE CONCAT(?, '%'))
+	`, model.JobPending, model.JobFailed, includeInterrupted, interruptedReason)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// FailInterrupted marks jobs left uploading by a crashed run as failed.
+// Jobs started within olderThan may belong to a worker still uploading them
+func (r *ListingJobRepository) FailInterrupted(ctx context.Context, olderThan time.Duration) (int, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE listing_jobs SET status = ?, reason = ?, finished_at = NOW()
+		WHERE status = ? AND started_at < NOW() - INTERVAL ? SECOND
+	`, model.JobFailed, interruptedReason, model.JobUploading, int(olderThan.Seconds()))
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// Requeue resets the product's job to pending, forgetting its old listing
+// and its attempts
+func (r *ListingJobRepository) Requeue(ctx context.Context, productID int64, reason string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO listing_jobs (product_id, status, reason) VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE status = VALUES(status), reason = VALUES(reason), attempts = 0,
+			listing_id = NULL, listing_url = NULL, finished_at = NULL
+	`, productID, model.JobPending, reason)
+	return err
+}
+
+// Queued reports whether the product has a job waiting or being uploaded
+func (r *ListingJobRepository) Queued(ctx context.Context, productID int64) (bool, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM listing_jobs WHERE product_id = ? AND status IN (?, ?)
+	`, productID, model.JobPending, model.JobUploading).Scan(&n)
+	return n > 0, err
+}
@@ -0,0 +1,192 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"seller-platform-crawler/internal/domain/model"
+	"seller-platform-crawler/internal/domain/ports"
+)
+
+const wallapopMarketplace = "wallapop"
+
+// MarketplaceListingRepository keeps synced listings in marketplace_listings
+type MarketplaceListingRepository struct {
+	db *sql.DB
+}
+
+// NewMarketplaceListingRepository creates a repository on an open database
+func NewMarketplaceListingRepository(db *sql.DB) *MarketplaceListingRepository {
+	return &MarketplaceListingRepository{db: db}
+}
+
+var _ ports.MarketplaceListingRepository = (*MarketplaceListingRepository)(nil)
+
+// Upsert stores l. The source product comes from l.ProductID, else from the
+// upload job that created the listing, else from the only Obramat product
+// with the same title; a link found earlier is kept
+func (r *MarketplaceListingRepository) Upsert(ctx context.Context, l model.MarketplaceListing, syncedAt time.Time) (int64, error) {
+	productID := l.ProductID
+	if productID == 0 {
+		var err error
+		productID, err = r.findProduct(ctx, l)
+		if err != nil {
+			return 0, err
+		}
+	}
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO marketplace_listings
+			(marketplace, listing_id, product_id, title, price, currency, views, favourites, status, url, last_synced_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			product_id = COALESCE(product_id, VALUES(product_id)),
+			title = VALUES(title), price = VALUES(price), currency = VALUES(currency),
+			views = VALUES(views), favourites = VALUES(favourites), status = VALUES(status),
+			url = COALESCE(VALUES(url), url), last_synced_at = VALUES(last_synced_at)
+	`, wallapopMarketplace, l.ListingID, nullID(productID), l.Title, l.Price, l.Currency,
+		l.Views, l.Favourites, l.Status, nullString(l.URL), syncedAt)
+	if err != nil {
+		return 0, err
+	}
+	err = r.db.QueryRowContext(ctx, `
+		SELECT COALESCE(product_id, 0) FROM marketplace_listings WHERE marketplace = ? AND listing_id = ?
+	`, wallapopMarketplace, l.ListingID).Scan(&productID)
+	return productID, err
+}
+
+func (r *MarketplaceListingRepository) findProduct(ctx context.Context, l model.MarketplaceListing) (int64, error) {
+	var id int64
+	err := r.db.QueryRowContext(ctx, `SELECT product_id FROM listing_jobs WHERE listing_id = ? LIMIT 1`, l.ListingID).Scan(&id)
+	if err == nil || !errors.Is(err, sql.ErrNoRows) {
+		return id, err
+	}
+	// titles we shortened on upload end in an ellipsis and match nothing
+	if l.Title == "" || strings.HasSuffix(l.Title, "…") {
+		return 0, nil
+	}
+	// a title shared by several products, e.g. old duplicates, links none
+	var matches int
+	err = r.db.QueryRowContext(ctx, `
+		SELECT COALESCE(MIN(id), 0), COUNT(*) FROM products
+		WHERE retailer = 'obramat' AND TRIM(title) = TRIM(?)
+	`, l.Title).Scan(&id, &matches)
+	if err != nil || matches != 1 {
+		return 0, err
+	}
+	return id, nil
+}
+
+// MarkMissing flags listings a full sync did not see
+func (r *MarketplaceListingRepository) MarkMissing(ctx context.Context, syncedAt time.Time) (int, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE marketplace_listings SET status = ?
+		WHERE marketplace = ? AND status <> ? AND (last_synced_at IS NULL OR last_synced_at < ?)
+	`, model.ListingRemoved, wallapopMarketplace, model.ListingRemoved, syncedAt)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+func nullID(id int64) any {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
+func nullString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// RecordSubmission upserts the submitted values of a listing
+func (r *MarketplaceListingRepository) RecordSubmission(ctx context.Context, s model.ListingSubmission) error {
+	photos, err := json.Marshal(s.Photos)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO marketplace_listings
+			(marketplace, listing_id, product_id, title, price, status, url, description, description_key, photos,
+			 source_price, source_stock, submitted_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW())
+		ON DUPLICATE KEY UPDATE
+			product_id = COALESCE(VALUES(product_id), product_id),
+			title = VALUES(title), price = VALUES(price), url = COALESCE(VALUES(url), url),
+			description = VALUES(description), description_key = VALUES(description_key), photos = VALUES(photos),
+			source_price = VALUES(source_price), source_stock = VALUES(source_stock), submitted_at = NOW()
+	`, wallapopMarketplace, s.ListingID, nullID(s.ProductID), s.Title, s.Price, model.ListingPublished,
+		nullString(s.URL), s.Description, nullString(s.DescriptionKey), string(photos), s.SourcePrice, s.SourceStock)
+	return err
+}
+
+// Linked returns live listings with a source product
+func (r *MarketplaceListingRepository) Linked(ctx context.Context) ([]model.LinkedListing, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT listing_id, product_id, status, COALESCE(price, 0), COALESCE(title, ''), COALESCE(url, ''),
+		       COALESCE(delisted_reason, ''), description, COALESCE(description_key, ''), photos, source_price, source_stock,
+		       submitted_at IS NOT NULL
+		FROM marketplace_listings
+		WHERE marketplace = ? AND product_id IS NOT NULL
+		  AND (status IN (?, ?) OR (status = ? AND delisted_reason IS NOT NULL))
+		ORDER BY id
+	`, wallapopMarketplace, model.ListingPublished, model.ListingReserved, model.ListingRemoved)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []model.LinkedListing
+	for rows.Next() {
+		var l model.LinkedListing
+		var title, descriptionKey string
+		var description, photos sql.NullString
+		var sourcePrice sql.NullFloat64
+		var sourceStock sql.NullInt64
+		var submitted bool
+		if err := rows.Scan(&l.ListingID, &l.ProductID, &l.Status, &l.Price, &title, &l.URL,
+			&l.DelistedReason, &description, &descriptionKey, &photos, &sourcePrice, &sourceStock, &submitted); err != nil {
+			return nil, err
+		}
+		if submitted {
+			s := &model.ListingSubmission{
+				ListingID:      l.ListingID,
+				URL:            l.URL,
+				ProductID:      l.ProductID,
+				Title:          title,
+				Description:    description.String,
+				DescriptionKey: descriptionKey,
+				Price:          l.Price,
+				SourcePrice:    sourcePrice.Float64,
+			}
+			if photos.Valid {
+				if err := json.Unmarshal([]byte(photos.String), &s.Photos); err != nil {
+					return nil, fmt.Errorf("listing %s photos: %w", l.ListingID, err)
+				}
+			}
+			if sourceStock.Valid {
+				n := int(sourceStock.Int64)
+				s.SourceStock = &n
+			}
+			l.Submission = s
+		}
+		out = append(out, l)
+	}
+	return out, rows.Err()
+}
+
+// SetDelisted stores a reservation, removal or reactivation
+func (r *MarketplaceListingRepository) SetDelisted(ctx context.Context, listingID, status, reason string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE marketplace_listings SET status = ?, delisted_reason = ? WHERE marketplace = ? AND listing_id = ?
+	`, status, nullString(reason), wallapopMarketplace, listingID)
+	return err
+}
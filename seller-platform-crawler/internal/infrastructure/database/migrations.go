@@ -0,0 +1,82 @@
+package database
+
+import "database/sql"
+
+// RunMigrations creates the tables owned by the seller crawler. The products
+// tables it reads are created by the consumer crawler
+func RunMigrations(db *sql.DB) error {
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS listing_jobs (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			product_id BIGINT NOT NULL,
+			status VARCHAR(16) NOT NULL DEFAULT 'pending',
+			attempts INT NOT NULL DEFAULT 0,
+			reason TEXT NULL,
+			listing_id VARCHAR(64) NULL,
+			listing_url VARCHAR(512) NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			started_at TIMESTAMP NULL,
+			finished_at TIMESTAMP NULL,
+			UNIQUE KEY uniq_listing_job_product (product_id),
+			INDEX idx_listing_jobs_status (status, id),
+			FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;`,
+		`CREATE TABLE IF NOT EXISTS marketplace_listings (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			marketplace VARCHAR(32) NOT NULL DEFAULT 'wallapop',
+			listing_id VARCHAR(64) NOT NULL,
+			product_id BIGINT NULL,
+			title TEXT,
+			price DECIMAL(12,2) NULL,
+			currency VARCHAR(8) DEFAULT 'EUR',
+			views INT NOT NULL DEFAULT 0,
+			favourites INT NOT NULL DEFAULT 0,
+			status VARCHAR(16) NOT NULL,
+			url VARCHAR(512) NULL,
+			first_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			last_synced_at TIMESTAMP NULL,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			UNIQUE KEY uniq_marketplace_listing (marketplace, listing_id),
+			INDEX idx_marketplace_listings_product (product_id),
+			INDEX idx_marketplace_listings_status (status),
+			FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE SET NULL
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;`,
+	}
+	for _, stmt := range stmts {
+		if _, err := db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	// columns added after a table was first created
+	columns := []struct{ table, column, ddl string }{
+		// what we last submitted to Wallapop, and the source product's price
+		// and stock at that time
+		{"marketplace_listings", "description", "TEXT NULL"},
+		{"marketplace_listings", "photos", "TEXT NULL"},
+		{"marketplace_listings", "source_price", "DECIMAL(12,2) NULL"},
+		{"marketplace_listings", "source_stock", "INT NULL"},
+		{"marketplace_listings", "submitted_at", "TIMESTAMP NULL"},
+		{"marketplace_listings", "delisted_reason", "VARCHAR(32) NULL"},
+		{"marketplace_listings", "description_key", "CHAR(64) NULL"},
+	}
+	for _, c := range columns {
+		if err := addColumnIfMissing(db, c.table, c.column, c.ddl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addColumnIfMissing(db *sql.DB, table, column, ddl string) error {
+	var n int
+	err := db.QueryRow(`
+		SELECT COUNT(*) FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND COLUMN_NAME = ?
+	`, table, column).Scan(&n)
+	if err != nil || n > 0 {
+		return err
+	}
+	_, err = db.Exec("ALTER TABLE " + table + " ADD COLUMN " + column + " " + ddl)
+	return err
+}
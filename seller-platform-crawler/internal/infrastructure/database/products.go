@@ -0,0 +1,165 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+
+	"seller-platform-crawler/internal/domain/model"
+	"seller-platform-crawler/internal/domain/ports"
+)
+
+// errNoSuchTable is MySQL's ER_NO_SUCH_TABLE
+const errNoSuchTable = 1146
+
+// ProductRepository reads products from the consumer crawler's tables
+type ProductRepository struct {
+	db *sql.DB
+}
+
+// NewProductRepository creates a repository on an open database
+func NewProductRepository(db *sql.DB) *ProductRepository {
+	return &ProductRepository{db: db}
+}
+
+var _ ports.ProductRepository = (*ProductRepository)(nil)
+
+// GetProduct loads a product and its images
+func (r *ProductRepository) GetProduct(ctx context.Context, id int64) (*model.Product, error) {
+	p := &model.Product{ID: id}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT source_url, COALESCE(reference, ''), COALESCE(title, ''), COALESCE(description, ''),
+		       COALESCE(price, 0), COALESCE(currency, 'EUR'), COALESCE(brand, ''), COALESCE(model, ''),
+		       COALESCE(ean, ''), COALESCE(category, ''), status
+		FROM products WHERE id = ?
+	`, id).Scan(&p.SourceURL, &p.Reference, &p.Title, &p.Description,
+		&p.Price, &p.Currency, &p.Brand, &p.Model, &p.EAN, &p.Category, &p.Status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ports.ErrProductNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT url FROM product_images WHERE product_id = ? ORDER BY position IS NULL, position, id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var url string
+		if err := rows.Scan(&url); err != nil {
+			return nil, err
+		}
+		p.Images = append(p.Images, url)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if p.Specs, err = r.specs(ctx, id); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// specs loads the product's characteristics, which the consumer crawler
+// stores in product_attributes. A database it has not migrated yet has no
+// such table, which yields none
+func (r *ProductRepository) specs(ctx context.Context, productID int64) ([]model.Spec, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT name, value FROM product_attributes WHERE product_id = ? ORDER BY position, id
+	`, productID)
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == errNoSuchTable {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []model.Spec
+	for rows.Next() {
+		var s model.Spec
+		if err := rows.Scan(&s.Name, &s.Value); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// StoreStock sums the known stock of the matching stores
+func (r *ProductRepository) StoreStock(ctx context.Context, productID int64, store string) (*int, error) {
+	var stock sql.NullInt64
+	pattern := "%" + store + "%"
+	err := r.db.QueryRowContext(ctx, `
+		SELECT SUM(stock) FROM product_availability
+		WHERE product_id = ? AND stock IS NOT NULL AND (store_city LIKE ? OR store_name LIKE ?)
+	`, productID, pattern, pattern).Scan(&stock)
+	if err != nil || !stock.Valid {
+		return nil, err
+	}
+	n := int(stock.Int64)
+	return &n, nil
+}
+
+// Candidates lists the active products with a price, without images
+func (r *ProductRepository) Candidates(ctx context.Context) ([]model.Product, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, source_url, COALESCE(reference, ''), COALESCE(title, ''), price, COALESCE(currency, 'EUR'),
+		       COALESCE(brand, ''), COALESCE(model, ''), COALESCE(ean, ''), COALESCE(category, ''), status
+		FROM products
+		WHERE status = ? AND price > 0
+		ORDER BY id
+	`, model.ProductActive)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []model.Product
+	for rows.Next() {
+		var p model.Product
+		if err := rows.Scan(&p.ID, &p.SourceURL, &p.Reference, &p.Title, &p.Price, &p.Currency,
+			&p.Brand, &p.Model, &p.EAN, &p.Category, &p.Status); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// StoreAvailability combines the unit counts and stock statuses of the
+// matching stores: any store with stock makes the product available
+func (r *ProductRepository) StoreAvailability(ctx context.Context, productID int64, store string) (model.StoreAvailability, error) {
+	var a model.StoreAvailability
+	var stock sql.NullInt64
+	var available, soldOut sql.NullBool
+	pattern := "%" + store + "%"
+	err := r.db.QueryRowContext(ctx, `
+		SELECT SUM(stock),
+		       MAX(stock > 0 OR status IN ('in_stock', 'low_stock')),
+		       MAX(stock = 0 OR status = 'out_of_stock')
+		FROM product_availability
+		WHERE product_id = ? AND (store_city LIKE ? OR store_name LIKE ?)
+	`, productID, pattern, pattern).Scan(&stock, &available, &soldOut)
+	if err != nil {
+		return a, err
+	}
+	if stock.Valid {
+		n := int(stock.Int64)
+		a.Stock = &n
+	}
+	switch {
+	case available.Valid && available.Bool:
+		in := true
+		a.InStock = &in
+	case soldOut.Valid && soldOut.Bool:
+		in := false
+		a.InStock = &in
+	}
+	return a, nil
+}
@@ -0,0 +1,94 @@
+package images
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"seller-platform-crawler/internal/domain/ports"
+)
+
+// maxImageBytes bounds a single download
+const maxImageBytes = 20 << 20
+
+// HTTPFetcher downloads images over plain HTTP
+type HTTPFetcher struct {
+	client *http.Client
+}
+
+// NewHTTPFetcher creates a fetcher with a per-image timeout
+func NewHTTPFetcher(timeout time.Duration) *HTTPFetcher {
+	return &HTTPFetcher{client: &http.Client{Timeout: timeout}}
+}
+
+var _ ports.ImageFetcher = (*HTTPFetcher)(nil)
+
+// Fetch returns the bytes and content type of an image
+func (f *HTTPFetcher) Fetch(ctx context.Context, url string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageBytes+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(data) > maxImageBytes {
+		return nil, "", fmt.Errorf("GET %s: image larger than %d bytes", url, maxImageBytes)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	return data, contentType, nil
+}
+
+// Download saves each image as dir/photo-<n>.<ext>
+func (f *HTTPFetcher) Download(ctx context.Context, urls []string, dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	var paths []string
+	for i, url := range urls {
+		data, contentType, err := f.Fetch(ctx, url)
+		if err != nil {
+			if ctx.Err() != nil {
+				return paths, ctx.Err()
+			}
+			slog.Warn("Image download failed", "step", "images", "url", url, "err", err)
+			continue
+		}
+		path := filepath.Join(dir, fmt.Sprintf("photo-%02d%s", i+1, extension(contentType)))
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return paths, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+func extension(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "image/png":
+		return ".png"
+	case "image/webp":
+		return ".webp"
+	default:
+		return ".jpg"
+	}
+}
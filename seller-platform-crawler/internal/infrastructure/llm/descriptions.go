@@ -0,0 +1,150 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"seller-platform-crawler/internal/domain/ports"
+)
+
+// maxResponseBytes bounds a completion response
+const maxResponseBytes = 1 << 20
+
+// systemPrompt sets the tone of the generated descriptions
+const systemPrompt = `Eres un vendedor particular en Wallapop. Escribes descripciones de anuncio en español, ` +
+	`cercanas y concretas, sin inventar características que no aparezcan en los datos del producto. ` +
+	`Sin emojis ni mayúsculas gritonas. Termina con unos pocos hashtags relevantes.`
+
+// DescriptionGenerator writes descriptions with an OpenAI-compatible chat
+// completions API
+type DescriptionGenerator struct {
+	endpoint string
+	apiKey   string
+	model    string
+	client   *http.Client
+}
+
+// NewDescriptionGenerator creates a generator. endpoint is the API base URL,
+// e.g. https://api.openai.com/v1; apiKey may be empty for local servers
+func NewDescriptionGenerator(endpoint, apiKey, model string, timeout time.Duration) *DescriptionGenerator {
+	return &DescriptionGenerator{
+		endpoint: strings.TrimRight(endpoint, "/"),
+		apiKey:   apiKey,
+		model:    model,
+		client:   &http.Client{Timeout: timeout},
+	}
+}
+
+var _ ports.DescriptionGenerator = (*DescriptionGenerator)(nil)
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model       string        `json:"model"`
+	Messages    []chatMessage `json:"messages"`
+	Temperature float64       `json:"temperature"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Generate asks the model for a description. The caller still enforces the
+// length limit, the prompt only asks for it
+func (g *DescriptionGenerator) Generate(ctx context.Context, req ports.DescriptionRequest) (string, error) {
+	body, err := json.Marshal(chatRequest{
+		Model: g.model,
+		Messages: []chatMessage{
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: prompt(req)},
+		},
+		Temperature: 0.7,
+	})
+	if err != nil {
+		return "", err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, g.endpoint+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if g.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+g.apiKey)
+	}
+	resp, err := g.client.Do(httpReq)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return "", err
+	}
+
+	var out chatResponse
+	if err := json.Unmarshal(data, &out); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("completion %s", resp.Status)
+		}
+		return "", fmt.Errorf("completion: %w", err)
+	}
+	if out.Error != nil {
+		return "", fmt.Errorf("completion %s: %s", resp.Status, out.Error.Message)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("completion %s", resp.Status)
+	}
+	if len(out.Choices) == 0 {
+		return "", errors.New("completion has no choices")
+	}
+	text := strings.TrimSpace(out.Choices[0].Message.Content)
+	if text == "" {
+		return "", errors.New("completion is empty")
+	}
+	return text, nil
+}
+
+// prompt lists the product data the model may use
+func prompt(req ports.DescriptionRequest) string {
+	p := req.Product
+	var b strings.Builder
+	fmt.Fprintf(&b, "Escribe la descripción de un anuncio de Wallapop de como máximo %d caracteres, hashtags incluidos.\n\n", req.MaxLength)
+	fmt.Fprintf(&b, "Producto: %s\n", p.Title)
+	for _, f := range []struct{ name, value string }{
+		{"Marca", p.Brand},
+		{"Modelo", p.Model},
+		{"Referencia", p.Reference},
+		{"Categoría", req.Category},
+		{"Estado", req.Condition},
+	} {
+		if f.value != "" {
+			fmt.Fprintf(&b, "%s: %s\n", f.name, f.value)
+		}
+	}
+	fmt.Fprintf(&b, "Precio: %.2f €\n", req.Price)
+	if len(p.Specs) > 0 {
+		b.WriteString("Características:\n")
+		for _, s := range p.Specs {
+			fmt.Fprintf(&b, "- %s: %s\n", s.Name, s.Value)
+		}
+	}
+	if d := strings.TrimSpace(p.Description); d != "" {
+		fmt.Fprintf(&b, "Descripción del fabricante: %s\n", d)
+	}
+	return b.String()
+}
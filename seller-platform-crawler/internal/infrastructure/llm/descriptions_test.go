@@ -0,0 +1,101 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"seller-platform-crawler/internal/domain/model"
+	"seller-platform-crawler/internal/domain/ports"
+)
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		name    string
+		apiKey  string
+		status  int
+		body    string
+		want    string
+		wantErr string
+	}{
+		{
+			name:   "choices",
+			apiKey: "secret",
+			status: http.StatusOK,
+			body:   `{"choices":[{"message":{"role":"assistant","content":"  Taladro como nuevo. #bricolaje \n"}}]}`,
+			want:   "Taladro como nuevo. #bricolaje",
+		},
+		{
+			name:    "error object",
+			status:  http.StatusTooManyRequests,
+			body:    `{"error":{"message":"rate limit reached"}}`,
+			wantErr: "completion 429 Too Many Requests: rate limit reached",
+		},
+		{
+			name:    "non-JSON error page",
+			status:  http.StatusBadGateway,
+			body:    `<html>Bad gateway</html>`,
+			wantErr: "completion 502 Bad Gateway",
+		},
+		{
+			name:    "no choices",
+			status:  http.StatusOK,
+			body:    `{"choices":[]}`,
+			wantErr: "completion has no choices",
+		},
+		{
+			name:    "empty content",
+			status:  http.StatusOK,
+			body:    `{"choices":[{"message":{"role":"assistant","content":"  "}}]}`,
+			wantErr: "completion is empty",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got chatRequest
+			var auth string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.URL.Path != "/v1/chat/completions" {
+					t.Errorf("request %s %s, want POST /v1/chat/completions", r.Method, r.URL.Path)
+				}
+				auth = r.Header.Get("Authorization")
+				if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+					t.Errorf("decode request: %v", err)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			g := NewDescriptionGenerator(srv.URL+"/v1/", tt.apiKey, "test-model", 5*time.Second)
+			text, err := g.Generate(context.Background(), ports.DescriptionRequest{
+				Product:   &model.Product{ID: 7, Title: "Taladro percutor", Brand: "Dexter"},
+				Category:  "Bricolaje",
+				Price:     49.99,
+				MaxLength: 640,
+			})
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil || text != tt.want {
+				t.Fatalf("Generate = %q, %v, want %q", text, err, tt.want)
+			}
+
+			wantAuth := ""
+			if tt.apiKey != "" {
+				wantAuth = "Bearer " + tt.apiKey
+			}
+			if auth != wantAuth {
+				t.Errorf("Authorization = %q, want %q", auth, wantAuth)
+			}
+			if got.Model != "test-model" || len(got.Messages) != 2 || !strings.Contains(got.Messages[1].Content, "Taladro percutor") {
+				t.Errorf("request = %+v", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"seller-platform-crawler/internal/domain/ports"
+)
+
+// Environment variables that override the secrets file
+const (
+	EnvEmail         = "WALLAPOP_EMAIL"
+	EnvPassword      = "WALLAPOP_PASSWORD"
+	EnvGoogleAccount = "WALLAPOP_GOOGLE_ACCOUNT"
+)
+
+// LoadCredentials reads credentials from a JSON secrets file, if path is not
+// empty, and then lets the environment override each field
+func LoadCredentials(path string) (ports.Credentials, error) {
+	var creds ports.Credentials
+	if path != "" {
+		info, err := os.Stat(path)
+		if err != nil {
+			return creds, err
+		}
+		if info.Mode().Perm()&0o077 != 0 {
+			slog.Warn("Secrets file is readable by other users, chmod 600 it", "path", path, "mode", info.Mode().Perm())
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return creds, err
+		}
+		if err := json.Unmarshal(data, &creds); err != nil {
+			return creds, fmt.Errorf("parse %s: %w", path, err)
+		}
+	}
+	override(&creds.Email, EnvEmail)
+	override(&creds.Password, EnvPassword)
+	override(&creds.GoogleAccount, EnvGoogleAccount)
+	return creds, nil
+}
+
+func override(field *string, env string) {
+	if v := os.Getenv(env); v != "" {
+		*field = v
+	}
+}
@@ -0,0 +1,108 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"seller-platform-crawler/internal/domain/ports"
+)
+
+// CookieFile is a plain JSON cookie file, used to import a session exported
+// from a desktop browser. Load understands both the SessionCookie format and
+// the export format of the Cookie-Editor and EditThisCookie extensions
+type CookieFile struct {
+	path string
+}
+
+// NewCookieFile creates a cookie file store at path
+func NewCookieFile(path string) *CookieFile {
+	return &CookieFile{path: path}
+}
+
+var _ ports.SessionStore = (*CookieFile)(nil)
+
+// exportedCookie accepts the field names of both formats
+type exportedCookie struct {
+	Name           string  `json:"name"`
+	Value          string  `json:"value"`
+	Domain         string  `json:"domain"`
+	Path           string  `json:"path"`
+	Expires        float64 `json:"expires"`
+	ExpirationDate float64 `json:"expirationDate"`
+	HTTPOnly       bool    `json:"http_only"`
+	HTTPOnlyCamel  bool    `json:"httpOnly"`
+	Secure         bool    `json:"secure"`
+	SameSite       string  `json:"same_site"`
+	SameSiteCamel  string  `json:"sameSite"`
+}
+
+// Save writes cookies in the SessionCookie format, readable only by the owner
+func (f *CookieFile) Save(cookies []ports.SessionCookie) error {
+	data, err := json.MarshalIndent(cookies, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(f.path, data, 0o600)
+}
+
+// Load reads the cookie file. A missing file is (nil, nil)
+func (f *CookieFile) Load() ([]ports.SessionCookie, error) {
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var raw []exportedCookie
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("cookie file %s: %w", f.path, err)
+	}
+	cookies := make([]ports.SessionCookie, 0, len(raw))
+	for _, c := range raw {
+		if c.Name == "" {
+			continue
+		}
+		expires := c.Expires
+		if expires == 0 {
+			expires = c.ExpirationDate
+		}
+		path := c.Path
+		if path == "" {
+			path = "/"
+		}
+		sameSite := c.SameSite
+		if sameSite == "" {
+			sameSite = c.SameSiteCamel
+		}
+		cookies = append(cookies, ports.SessionCookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   c.Domain,
+			Path:     path,
+			Expires:  expires,
+			HTTPOnly: c.HTTPOnly || c.HTTPOnlyCamel,
+			Secure:   c.Secure,
+			SameSite: normaliseSameSite(sameSite),
+		})
+	}
+	return cookies, nil
+}
+
+// normaliseSameSite maps extension values to the CDP ones; unknown values
+// leave the browser default
+func normaliseSameSite(v string) string {
+	switch strings.ToLower(v) {
+	case "strict":
+		return "Strict"
+	case "lax":
+		return "Lax"
+	case "none", "no_restriction":
+		return "None"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,120 @@
+package session
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"seller-platform-crawler/internal/domain/ports"
+)
+
+// File layout: magic, salt, nonce, AES-256-GCM ciphertext of the JSON cookies
+var magic = []byte("WSS1")
+
+const (
+	saltSize  = 16
+	kdfRounds = 600000
+	keySize   = 32
+)
+
+// EncryptedFileStore keeps session cookies in a file encrypted with a key
+// derived from a passphrase
+type EncryptedFileStore struct {
+	path       string
+	passphrase string
+}
+
+// NewEncryptedFileStore creates a store at path. The passphrase must not be
+// empty
+func NewEncryptedFileStore(path, passphrase string) (*EncryptedFileStore, error) {
+	if passphrase == "" {
+		return nil, errors.New("session store: empty passphrase")
+	}
+	return &EncryptedFileStore{path: path, passphrase: passphrase}, nil
+}
+
+var _ ports.SessionStore = (*EncryptedFileStore)(nil)
+
+// Save encrypts cookies and replaces the file atomically
+func (s *EncryptedFileStore) Save(cookies []ports.SessionCookie) error {
+	plain, err := json.Marshal(cookies)
+	if err != nil {
+		return err
+	}
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	aead, err := s.aead(salt)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	buf.Write(magic)
+	buf.Write(salt)
+	buf.Write(nonce)
+	buf.Write(aead.Seal(nil, nonce, plain, magic))
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// Load decrypts the saved cookies. A wrong passphrase or a tampered file is
+// reported as an error, a missing file as (nil, nil)
+func (s *EncryptedFileStore) Load() ([]ports.SessionCookie, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < len(magic)+saltSize || !bytes.Equal(data[:len(magic)], magic) {
+		return nil, fmt.Errorf("session store %s: not a session file", s.path)
+	}
+	data = data[len(magic):]
+	salt, data := data[:saltSize], data[saltSize:]
+	aead, err := s.aead(salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, fmt.Errorf("session store %s: truncated file", s.path)
+	}
+	nonce, sealed := data[:aead.NonceSize()], data[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, sealed, magic)
+	if err != nil {
+		return nil, fmt.Errorf("session store %s: decrypt failed (wrong passphrase?): %w", s.path, err)
+	}
+	var cookies []ports.SessionCookie
+	if err := json.Unmarshal(plain, &cookies); err != nil {
+		return nil, fmt.Errorf("session store %s: %w", s.path, err)
+	}
+	return cookies, nil
+}
+
+func (s *EncryptedFileStore) aead(salt []byte) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, s.passphrase, salt, kdfRounds, keySize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -2,47 +2,311 @@ package main
 
 import (
 	"context"
-	"log"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"seller-platform-crawler/internal/application"
+	"seller-platform-crawler/internal/domain/ports"
 	"seller-platform-crawler/internal/infrastructure/browser"
 	"seller-platform-crawler/internal/infrastructure/database"
+	"seller-platform-crawler/internal/infrastructure/images"
+	"seller-platform-crawler/internal/infrastructure/llm"
+	"seller-platform-crawler/internal/infrastructure/logging"
+	"seller-platform-crawler/internal/infrastructure/secrets"
+	"seller-platform-crawler/internal/infrastructure/session"
 )
 
 func main() {
+	var rotationFile string
+	var logFormat string
+	var logLevel string
+	var sessionFile string
+	var authStrategy string
+	var secretsFile string
+	var cookieFile string
+	var googleAccount string
+	var manualAssist bool
+	var manualTimeout time.Duration
+	var publishID int64
+	var enqueueIDs string
+	var uploadQueue bool
+	var retryFailed bool
+	var retryInterrupted bool
+	var syncListings bool
+	var updateListings bool
+	var reconcile bool
+	var delistMode string
+	var dryRun bool
+	var store string
+	var pricingFile string
+	var categoryFile string
+	var priceReport bool
+	var descriptionSource string
+	var templateDir string
+	var hashtagWords string
+	var llmURL string
+	var llmModel string
+	thresholds := application.DefaultUpdateThresholds
+	batchOpts := application.DefaultBatchOptions
+	publishOpts := application.DefaultPublishOptions
+	flag.StringVar(&rotationFile, "rotation", "", "JSON file with proxies and browser fingerprints to rotate (disabled if empty)")
+	flag.StringVar(&logFormat, "log-format", "text", "log output format: text or json")
+	flag.StringVar(&logLevel, "log-level", "info", "minimum log level: debug, info, warn or error")
+	flag.StringVar(&sessionFile, "session-file", "./wallapop-session.enc", "encrypted file the Wallapop session cookies are saved to (needs WALLAPOP_SESSION_KEY)")
+	flag.StringVar(&authStrategy, "auth", application.AuthGoogle, "login strategy: google, password or cookies")
+	flag.StringVar(&secretsFile, "secrets", "", "JSON file with email, password and google_account (WALLAPOP_EMAIL, WALLAPOP_PASSWORD and WALLAPOP_GOOGLE_ACCOUNT override it)")
+	flag.StringVar(&cookieFile, "cookie-file", "", "JSON cookie export from a signed-in browser, used by -auth cookies")
+	flag.StringVar(&googleAccount, "google-account", "", "Google account email to pick in the sign-in popup (first account if empty)")
+	flag.BoolVar(&manualAssist, "manual-assist", false, "pause the Google sign-in for a human when it asks for a password or 2FA")
+	flag.DurationVar(&manualTimeout, "manual-timeout", 5*time.Minute, "how long -manual-assist waits for the human")
+	flag.Int64Var(&publishID, "publish", 0, "id of a product in the products table to publish on Wallapop")
+	flag.StringVar(&enqueueIDs, "enqueue", "", "comma-separated product ids to add to the upload queue")
+	flag.BoolVar(&uploadQueue, "upload-queue", false, "upload the pending jobs of the queue")
+	flag.IntVar(&batchOpts.MaxItems, "batch-size", 0, "stop -upload-queue after this many uploads (0 drains the queue)")
+	flag.DurationVar(&batchOpts.MinGap, "upload-gap-min", batchOpts.MinGap, "minimum pause between two queued uploads")
+	flag.DurationVar(&batchOpts.MaxGap, "upload-gap-max", batchOpts.MaxGap, "maximum pause between two queued uploads")
+	flag.BoolVar(&retryFailed, "retry-failed", false, "move failed upload jobs back to pending")
+	flag.BoolVar(&retryInterrupted, "retry-interrupted", false, "with -retry-failed, also retry jobs interrupted mid-upload (check Wallapop for a live listing first)")
+	flag.BoolVar(&syncListings, "sync-listings", false, "read our Wallapop listings into marketplace_listings")
+	flag.BoolVar(&updateListings, "update-listings", false, "edit listings whose source price or stock changed beyond the thresholds")
+	flag.BoolVar(&reconcile, "reconcile", false, "reserve or delete listings whose product ran out of stock and bring them back when it returns")
+	flag.StringVar(&delistMode, "delist-mode", application.DelistReserve, "what -reconcile does to a listing that ran out: reserve or delete")
+	flag.BoolVar(&dryRun, "dry-run", false, "log what -update-listings or -reconcile would change without submitting")
+	flag.StringVar(&store, "store", "Badalona", "Obramat store (city or name) whose stock backs the listings")
+	flag.Float64Var(&thresholds.PriceChange, "price-threshold", thresholds.PriceChange, "relative source price change that triggers a listing update")
+	flag.Float64Var(&thresholds.MinPriceChange, "min-price-change", thresholds.MinPriceChange, "smallest source price change in euros that triggers a listing update")
+	flag.IntVar(&thresholds.StockChange, "stock-threshold", thresholds.StockChange, "store stock change in units that triggers a listing update")
+	flag.StringVar(&pricingFile, "pricing", "", "JSON file with the rules that turn Obramat prices into listing prices (prices are copied if empty)")
+	flag.BoolVar(&priceReport, "price-report", false, "log the listing price of every active product under the -pricing rules, publishing nothing")
+	flag.StringVar(&descriptionSource, "descriptions", "source", "how listing descriptions are written: source (the product description), template, or llm (templates as fallback)")
+	flag.StringVar(&templateDir, "description-templates", "", "directory with <category>.tmpl and default.tmpl description templates (built-in template if empty)")
+	flag.StringVar(&hashtagWords, "hashtags", "", "comma-separated keywords added as hashtags to every description")
+	flag.StringVar(&llmURL, "llm-url", "https://api.openai.com/v1", "base URL of the OpenAI-compatible API used by -descriptions llm (key in LLM_API_KEY)")
+	flag.StringVar(&llmModel, "llm-model", "gpt-4o-mini", "model used by -descriptions llm")
+	flag.StringVar(&publishOpts.Category, "category", publishOpts.Category, "Wallapop category of listings whose Obramat category has no -category-map entry, as shown in the upload form")
+	flag.StringVar(&categoryFile, "category-map", "", "JSON file mapping Obramat categories to Wallapop categories (every listing uses -category if empty)")
+	flag.StringVar(&publishOpts.Condition, "condition", publishOpts.Condition, "condition of published listings, as shown in the upload form")
+	flag.StringVar(&publishOpts.PostalCode, "postal-code", "", "postal code of published listings (profile location if empty)")
+	flag.Parse()
+
+	if err := logging.Setup(logFormat, logLevel); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	slog.SetDefault(slog.Default().With("run_id", logging.NewRunID()))
+
+	var pricing application.PricingRules
+	if pricingFile != "" {
+		var err error
+		if pricing, err = application.LoadPricingRules(pricingFile); err != nil {
+			fatal("pricing rules load failed", "path", pricingFile, "err", err)
+		}
+	}
+	categories := application.NewCategoryMap(publishOpts.Category)
+	if categoryFile != "" {
+		var err error
+		if categories, err = application.LoadCategoryMap(categoryFile, publishOpts.Category); err != nil {
+			fatal("category map load failed", "path", categoryFile, "err", err)
+		}
+	}
+	if delistMode != application.DelistReserve && delistMode != application.DelistDelete {
+		fatal("invalid -delist-mode, want reserve or delete", "value", delistMode)
+	}
+	var descriptions ports.DescriptionGenerator
+	if descriptionSource != "source" {
+		var keywords []string
+		for _, w := range strings.Split(hashtagWords, ",") {
+			if w = strings.TrimSpace(w); w != "" {
+				keywords = append(keywords, w)
+			}
+		}
+		templates, err := application.NewTemplateDescriptions(templateDir, keywords)
+		if err != nil {
+			fatal("description templates load failed", "path", templateDir, "err", err)
+		}
+		switch descriptionSource {
+		case "template":
+			descriptions = templates
+		case "llm":
+			gen := llm.NewDescriptionGenerator(llmURL, os.Getenv("LLM_API_KEY"), llmModel, 60*time.Second)
+			descriptions = application.NewFallbackDescriptions(gen, templates)
+		default:
+			fatal("invalid -descriptions, want template, llm or source", "value", descriptionSource)
+		}
+	}
+
 	// Initialize database adapter
 	dsn := "root:root@tcp(localhost:3306)/obramat?parseTime=true&charset=utf8mb4&loc=Local"
 	dbAdapter := database.NewMySQLAdapter(dsn)
 	
 	if err := dbAdapter.Connect(); err != nil {
-		log.Fatalf("db connection failed: %v", err)
+		fatal("db connection failed", "err", err)
 	}
 	defer dbAdapter.Close()
 	
 	if err := dbAdapter.Ping(); err != nil {
-		log.Fatalf("db ping failed: %v", err)
+		fatal("db ping failed", "err", err)
+	}
+	slog.Info("db connection successful")
+	if err := database.RunMigrations(dbAdapter.GetDB()); err != nil {
+		fatal("migrations failed", "err", err)
+	}
+	jobs := database.NewListingJobRepository(dbAdapter.GetDB())
+	if enqueueIDs != "" {
+		ids, err := parseIDs(enqueueIDs)
+		if err != nil {
+			fatal("invalid -enqueue", "err", err)
+		}
+		n, err := jobs.Enqueue(context.Background(), ids)
+		if err != nil {
+			fatal("enqueue failed", "err", err)
+		}
+		slog.Info("queued products", "requested", len(ids), "added", n)
+	}
+	if retryFailed {
+		n, err := jobs.RetryFailed(context.Background(), retryInterrupted)
+		if err != nil {
+			fatal("retry failed jobs", "err", err)
+		}
+		slog.Info("failed jobs re-queued", "jobs", n)
+	}
+	products := database.NewProductRepository(dbAdapter.GetDB())
+	if priceReport {
+		if _, err := application.PriceReport(context.Background(), products, pricing, categories); err != nil {
+			fatal("price report failed", "err", err)
+		}
+	}
+	// the queue and report commands only touch the database; skip Chrome
+	// and the login unless a Wallapop action was asked for as well
+	browserWork := publishID != 0 || uploadQueue || syncListings || updateListings || reconcile
+	if !browserWork && (enqueueIDs != "" || retryFailed || priceReport) {
+		return
 	}
-	log.Printf("db connection successful")
 
 	// Initialize browser adapter
 	browserAdapter := browser.NewChromeDPAdapter("./chrome-profile", false)
+	if rotationFile != "" {
+		rotator, err := browser.LoadRotator(rotationFile)
+		if err != nil {
+			fatal("rotation config load failed", "path", rotationFile, "err", err)
+		}
+		browserAdapter.SetRotator(rotator)
+	}
 	
+	defer browserAdapter.Close()
+
 	// Initialize authentication service
-	authService := application.NewWallapopAuthService(browserAdapter)
-	defer authService.Close()
+	creds, err := secrets.LoadCredentials(secretsFile)
+	if err != nil {
+		fatal("secrets load failed", "path", secretsFile, "err", err)
+	}
+	authConfig := application.AuthConfig{
+		Strategy:    authStrategy,
+		Credentials: creds,
+		Google: application.GoogleLoginOptions{
+			Account:       googleAccount,
+			ManualAssist:  manualAssist,
+			ManualTimeout: manualTimeout,
+		},
+	}
+	if cookieFile != "" {
+		authConfig.CookieSource = session.NewCookieFile(cookieFile)
+	}
+	if key := os.Getenv("WALLAPOP_SESSION_KEY"); key != "" && sessionFile != "" {
+		sessionStore, err := session.NewEncryptedFileStore(sessionFile, key)
+		if err != nil {
+			fatal("session store setup failed", "path", sessionFile, "err", err)
+		}
+		authConfig.Store = sessionStore
+	} else {
+		slog.Info("session persistence disabled, set WALLAPOP_SESSION_KEY to enable it")
+	}
+	authService, err := application.NewAuthService(browserAdapter, authConfig)
+	if err != nil {
+		fatal("auth setup failed", "strategy", authStrategy, "err", err)
+	}
 	
 	// Perform login
-	if err := authService.Login(context.Background()); err != nil {
-		log.Fatalf("login failed: %v", err)
+	err = authService.Login(context.Background())
+	if rerr := browserAdapter.ReportResult(err); rerr != nil {
+		fatal("browser restart failed", "err", rerr)
+	}
+	if err != nil {
+		fatal("login failed", "err", err)
+	}
+
+	listings := database.NewMarketplaceListingRepository(dbAdapter.GetDB())
+	publisher := application.NewListingPublisher(browserAdapter, products, images.NewHTTPFetcher(30*time.Second), publishOpts)
+	publisher.RecordSubmissions(listings, store)
+	publisher.SetPricing(pricing)
+	publisher.SetCategories(categories)
+	if descriptions != nil {
+		publisher.SetDescriptions(descriptions)
+	}
+	if publishID != 0 {
+		listing, err := publisher.Publish(context.Background(), publishID)
+		if err != nil {
+			fatal("publish failed", "product_id", publishID, "err", err)
+		}
+		slog.Info("published", "product_id", publishID, "listing_id", listing.ID, "url", listing.URL)
+	}
+	if uploadQueue {
+		bulk := application.NewBulkPublisher(jobs, publisher, browserAdapter)
+		res, err := bulk.Run(context.Background(), batchOpts)
+		if err != nil {
+			fatal("upload queue stopped", "published", res.Published, "failed", res.Failed, "err", err)
+		}
+	}
+
+	if syncListings {
+		sync := application.NewListingSync(browserAdapter, listings)
+		if _, err := sync.Sync(context.Background()); err != nil {
+			fatal("listing sync failed", "err", err)
+		}
+	}
+	if reconcile {
+		reconciler, err := application.NewReconciler(browserAdapter, products, listings, jobs, store, delistMode)
+		if err != nil {
+			fatal("invalid -delist-mode", "err", err)
+		}
+		if _, err := reconciler.Run(context.Background(), dryRun); err != nil {
+			fatal("reconciliation failed", "err", err)
+		}
+	}
+	if updateListings {
+		updater := application.NewListingUpdater(browserAdapter, products, listings, publisher, thresholds, store)
+		if _, err := updater.Run(context.Background(), dryRun); err != nil {
+			fatal("listing update failed", "err", err)
+		}
 	}
 
 	// TODO: implement crawler logic here
+}
 
-	// TODO: implement endpoint to fetch uploaded product data from wallapop
-	// TODO: implement endpoint to upload one product to wallapop
-	// TODO: implement endpoint to upload multiple products to wallapop
-	// TODO: implement endpoint to update single product
+// parseIDs parses a comma-separated list of product ids
+func parseIDs(s string) ([]int64, error) {
+	var ids []int64
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(f, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("product id %q: %w", f, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
 
-	// TODO: implement function to generate creative description for product based on its data
-	// TODO: implement function to fetch image binary data from URL
+// fatal logs at error level and exits, like log.Fatalf
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
 }